@@ -0,0 +1,93 @@
+// aka/5g_aka.go
+package aka
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+const (
+	SqnLen      = 6  // octets
+	AmfLen      = 2  // octets
+	RandLen     = 16 // octets
+	XresStarLen = 16 // octets
+)
+
+// Algorithm is the set of authentication functions of a subscriber, such as Milenage or TUAK
+type Algorithm interface {
+	ResyncAlgorithm
+	// F1 computes MAC-A
+	F1(rand, sqn, amf []byte) ([]byte, error)
+	// F2345 computes RES, CK, IK and AK
+	F2345(rand []byte) (res, ck, ik, ak []byte, err error)
+}
+
+// Vector5G is a 5G home environment authentication vector with the derived KSEAF
+type Vector5G struct {
+	Rand      []byte
+	Autn      []byte
+	XresStar  []byte
+	HxresStar []byte
+	Kausf     []byte
+	Kseaf     []byte
+}
+
+// Generate5G computes the 5G-AKA authentication vector for RAND, SQN and AMF
+// in the given serving network (TS 33.501 clause 6.1.3.2 and Annex A)
+func Generate5G(alg Algorithm, rand, sqn, amf []byte, servingNetworkName string) (*Vector5G, error) {
+	if len(rand) != RandLen {
+		return nil, fmt.Errorf("RAND must be %d octets, got %d", RandLen, len(rand))
+	}
+	if len(sqn) != SqnLen {
+		return nil, fmt.Errorf("SQN must be %d octets, got %d", SqnLen, len(sqn))
+	}
+	if len(amf) != AmfLen {
+		return nil, fmt.Errorf("AMF must be %d octets, got %d", AmfLen, len(amf))
+	}
+	if err := ValidateServingNetworkName(servingNetworkName); err != nil {
+		return nil, err
+	}
+
+	macA, err := alg.F1(rand, sqn, amf)
+	if err != nil {
+		return nil, err
+	}
+	res, ck, ik, ak, err := alg.F2345(rand)
+	if err != nil {
+		return nil, err
+	}
+
+	// AUTN = SQN xor AK || AMF || MAC-A
+	sqnXorAk := make([]byte, SqnLen)
+	for i := range sqnXorAk {
+		sqnXorAk[i] = sqn[i] ^ ak[i]
+	}
+	autn := append(append(append([]byte{}, sqnXorAk...), amf...), macA...)
+
+	snName := []byte(servingNetworkName)
+	ckIk := append(append([]byte{}, ck...), ik...)
+
+	xresStar := ResStar(ckIk, snName, rand, res)
+	kausf := KDF(ckIk, FcKausf5GAka, snName, sqnXorAk)
+
+	return &Vector5G{
+		Rand:      append([]byte{}, rand...),
+		Autn:      autn,
+		XresStar:  xresStar,
+		HxresStar: HresStar(rand, xresStar),
+		Kausf:     kausf,
+		Kseaf:     KDF(kausf, FcKseaf, snName),
+	}, nil
+}
+
+// ResStar derives RES* (or XRES*) from CK || IK as the 128 least significant bits of the KDF output (Annex A.4)
+func ResStar(ckIk, servingNetworkName, rand, res []byte) []byte {
+	out := KDF(ckIk, FcResStar, servingNetworkName, rand, res)
+	return out[len(out)-XresStarLen:]
+}
+
+// HresStar derives HRES* (or HXRES*) as the 128 least significant bits of SHA-256(RAND || RES*) (Annex A.5)
+func HresStar(rand, resStar []byte) []byte {
+	sum := sha256.Sum256(append(append([]byte{}, rand...), resStar...))
+	return sum[len(sum)-XresStarLen:]
+}
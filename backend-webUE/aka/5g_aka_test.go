@@ -0,0 +1,123 @@
+package aka
+
+import (
+	"backend-webUE/milenage"
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Milenage test set 1 of TS 35.208
+const (
+	testK    = "465b5ce8b199b49faa5f0a2ee238a6bc"
+	testOp   = "cdc202d5123e20f62b6d676ac72cb318"
+	testRand = "23553cbe9637a89d218ae64dae47bf35"
+	testSqn  = "ff9bb4d0b607"
+	testAmf  = "b9b9"
+	testCk   = "b40ba9a3c58b2a05bbf0d987b21bf8cb"
+	testIk   = "f769bcd751044604127672711c6d3441"
+	testRes  = "a54211d5e3ba50bf"
+	testAk   = "aa689c648370"
+	testMacA = "4a9ffac354dfafb3"
+	testSn   = "5G:mnc093.mcc208.3gppnetwork.org"
+)
+
+// Known answers for test set 1 with serving network name testSn, computed independently of this
+// package with OpenSSL's HMAC-SHA-256 and SHA-256 over the TS 33.220 Annex B.2 encoding of the
+// TS 33.501 Annex A inputs
+const (
+	testKdf       = "f5d5d825e8b0c4fc3646d00c1c447547e08c8a6e3595938d6d1551b2b522ad79"
+	testAutn      = "55f328b43577b9b94a9ffac354dfafb3"
+	testXresStar  = "5cc9527f4d21c43bee83a15443acf1c4"
+	testHxresStar = "6970075e3c8245fdc2073003cf166279"
+	testKausf     = "f2e35260f85194d4f891504d02111e56689ac23dd393bee3abbcc5bfbc013ef9"
+	testKseaf     = "cfddde483bd1318a412e98870f556410905be4fb7500abed93ee16af71bbb3fa"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+func TestKDF(t *testing.T) {
+	key := mustHex(t, testCk+testIk)
+	want := mustHex(t, testKdf)
+	if got := KDF(key, FcResStar, []byte(testSn), mustHex(t, testRand)); !bytes.Equal(got, want) {
+		t.Fatalf("KDF = %x, want %x", got, want)
+	}
+}
+
+func TestGenerate5G(t *testing.T) {
+	m, err := milenage.New(mustHex(t, testK), mustHex(t, testOp))
+	if err != nil {
+		t.Fatalf("milenage.New failed: %v", err)
+	}
+	rand, sqn, amf := mustHex(t, testRand), mustHex(t, testSqn), mustHex(t, testAmf)
+
+	av, err := Generate5G(m, rand, sqn, amf, testSn)
+	if err != nil {
+		t.Fatalf("Generate5G failed: %v", err)
+	}
+
+	for _, check := range []struct {
+		name     string
+		got      []byte
+		expected string
+	}{
+		{"AUTN", av.Autn, testAutn},
+		{"RAND", av.Rand, testRand},
+		{"XRES*", av.XresStar, testXresStar},
+		{"HXRES*", av.HxresStar, testHxresStar},
+		{"KAUSF", av.Kausf, testKausf},
+		{"KSEAF", av.Kseaf, testKseaf},
+	} {
+		if want := mustHex(t, check.expected); !bytes.Equal(check.got, want) {
+			t.Errorf("%s = %x, want %x", check.name, check.got, want)
+		}
+	}
+
+	// The serving network name binds the keys to the serving network
+	other, err := Generate5G(m, rand, sqn, amf, ServingNetworkName("001", "01"))
+	if err != nil {
+		t.Fatalf("Generate5G failed: %v", err)
+	}
+	if bytes.Equal(other.Kseaf, av.Kseaf) || bytes.Equal(other.XresStar, av.XresStar) {
+		t.Fatal("keys do not depend on the serving network name")
+	}
+}
+
+func TestGenerate5GInvalidInput(t *testing.T) {
+	m, err := milenage.New(mustHex(t, testK), mustHex(t, testOp))
+	if err != nil {
+		t.Fatalf("milenage.New failed: %v", err)
+	}
+	rand, sqn, amf := mustHex(t, testRand), mustHex(t, testSqn), mustHex(t, testAmf)
+
+	if _, err := Generate5G(m, rand[:15], sqn, amf, testSn); err == nil {
+		t.Error("Generate5G accepted a short RAND")
+	}
+	if _, err := Generate5G(m, rand, sqn[:5], amf, testSn); err == nil {
+		t.Error("Generate5G accepted a short SQN")
+	}
+	if _, err := Generate5G(m, rand, sqn, amf[:1], testSn); err == nil {
+		t.Error("Generate5G accepted a short AMF")
+	}
+	for _, sn := range []string{"", "5G:", "mnc093.mcc208.3gppnetwork.org"} {
+		if _, err := Generate5G(m, rand, sqn, amf, sn); err == nil {
+			t.Errorf("Generate5G accepted serving network name %q", sn)
+		}
+	}
+}
+
+func TestServingNetworkName(t *testing.T) {
+	if got := ServingNetworkName("208", "93"); got != testSn {
+		t.Fatalf("ServingNetworkName = %s, want %s", got, testSn)
+	}
+	if got := ServingNetworkName("310", "410"); got != "5G:mnc410.mcc310.3gppnetwork.org" {
+		t.Fatalf("ServingNetworkName = %s", got)
+	}
+}
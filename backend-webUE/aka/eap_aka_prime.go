@@ -0,0 +1,122 @@
+// aka/eap_aka_prime.go
+package aka
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+)
+
+// FcCkIkPrime is the FC value of the CK' and IK' derivation (TS 33.402 Annex A.2)
+const FcCkIkPrime = 0x20
+
+// Key lengths of EAP-AKA' (RFC 9048 section 3.3)
+const (
+	MkLen    = 208 // octets, 1664 bits
+	KEncrLen = 16  // octets
+	KAutLen  = 32  // octets
+	KReLen   = 32  // octets
+	MskLen   = 64  // octets
+	EmskLen  = 64  // octets
+)
+
+// EapAkaPrimeKeys are the keys an EAP-AKA' server and peer derive from CK and IK
+type EapAkaPrimeKeys struct {
+	CkPrime []byte
+	IkPrime []byte
+	Mk      []byte
+	KEncr   []byte
+	KAut    []byte
+	KRe     []byte
+	Msk     []byte
+	Emsk    []byte
+}
+
+// CkIkPrime derives CK' and IK' from CK, IK, the access network name and SQN xor AK
+func CkIkPrime(ck, ik []byte, networkName string, sqnXorAk []byte) (ckPrime, ikPrime []byte) {
+	key := append(append([]byte{}, ck...), ik...)
+	out := KDF(key, FcCkIkPrime, []byte(networkName), sqnXorAk)
+	return out[:16], out[16:]
+}
+
+// PRFPrime is the pseudo-random function PRF' of RFC 9048 section 3.4.1 producing n octets:
+// T1 = HMAC-SHA-256(K, S | 0x01), Ti = HMAC-SHA-256(K, Ti-1 | S | i)
+func PRFPrime(key, s []byte, n int) []byte {
+	var out, t []byte
+	for i := 1; len(out) < n; i++ {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(t)
+		mac.Write(s)
+		mac.Write([]byte{byte(i)})
+		t = mac.Sum(nil)
+		out = append(out, t...)
+	}
+	return out[:n]
+}
+
+// DeriveEapAkaPrime derives the EAP-AKA' keys from CK, IK, the access network name,
+// SQN xor AK (the first six octets of AUTN) and the peer identity
+func DeriveEapAkaPrime(ck, ik []byte, networkName string, sqnXorAk []byte, identity string) (*EapAkaPrimeKeys, error) {
+	if len(ck) != 16 || len(ik) != 16 {
+		return nil, fmt.Errorf("CK and IK must be 16 octets, got %d and %d", len(ck), len(ik))
+	}
+	if len(sqnXorAk) != SqnLen {
+		return nil, fmt.Errorf("SQN xor AK must be %d octets, got %d", SqnLen, len(sqnXorAk))
+	}
+	if networkName == "" {
+		return nil, fmt.Errorf("network name must not be empty")
+	}
+
+	ckPrime, ikPrime := CkIkPrime(ck, ik, networkName, sqnXorAk)
+
+	// MK = PRF'(IK'|CK', "EAP-AKA'"|Identity)
+	key := append(append([]byte{}, ikPrime...), ckPrime...)
+	mk := PRFPrime(key, []byte("EAP-AKA'"+identity), MkLen)
+
+	keys := &EapAkaPrimeKeys{CkPrime: ckPrime, IkPrime: ikPrime, Mk: mk}
+	rest := mk
+	for _, k := range []struct {
+		dst *[]byte
+		n   int
+	}{
+		{&keys.KEncr, KEncrLen}, {&keys.KAut, KAutLen}, {&keys.KRe, KReLen}, {&keys.Msk, MskLen}, {&keys.Emsk, EmskLen},
+	} {
+		*k.dst, rest = rest[:k.n], rest[k.n:]
+	}
+	return keys, nil
+}
+
+// GenerateEapAkaPrime runs the subscriber's authentication functions for RAND, SQN and AMF
+// and derives the EAP-AKA' keys. It also returns AUTN and XRES for the challenge.
+func GenerateEapAkaPrime(alg Algorithm, rand, sqn, amf []byte, networkName, identity string) (autn, xres []byte, keys *EapAkaPrimeKeys, err error) {
+	if len(rand) != RandLen {
+		return nil, nil, nil, fmt.Errorf("RAND must be %d octets, got %d", RandLen, len(rand))
+	}
+	if len(sqn) != SqnLen {
+		return nil, nil, nil, fmt.Errorf("SQN must be %d octets, got %d", SqnLen, len(sqn))
+	}
+	if len(amf) != AmfLen {
+		return nil, nil, nil, fmt.Errorf("AMF must be %d octets, got %d", AmfLen, len(amf))
+	}
+
+	macA, err := alg.F1(rand, sqn, amf)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	res, ck, ik, ak, err := alg.F2345(rand)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sqnXorAk := make([]byte, SqnLen)
+	for i := range sqnXorAk {
+		sqnXorAk[i] = sqn[i] ^ ak[i]
+	}
+	autn = append(append(append([]byte{}, sqnXorAk...), amf...), macA...)
+
+	keys, err = DeriveEapAkaPrime(ck, ik, networkName, sqnXorAk, identity)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return autn, res, keys, nil
+}
@@ -0,0 +1,105 @@
+package aka
+
+import (
+	"backend-webUE/milenage"
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// RFC 9048 Appendix C test vectors
+var eapAkaPrimeTestCases = []struct {
+	name, identity, networkName                   string
+	autn, ik, ck                                  string
+	ckPrime, ikPrime, kEncr, kAut, kRe, msk, emsk string
+}{
+	{
+		name:        "test case 1",
+		identity:    "0555444333222111",
+		networkName: "WLAN",
+		autn:        "bb52e91c747ac3ab2a5c23d15ee351d5",
+		ik:          "9744871ad32bf9bbd1dd5ce54e3e2e5a",
+		ck:          "5349fbe098649f948f5d2e973a81c00f",
+		ckPrime:     "0093962d0dd84aa5684b045c9edffa04",
+		ikPrime:     "ccfc230ca74fcc96c0a5d61164f5a76c",
+		kEncr:       "766fa0a6c317174b812d52fbcd11a179",
+		kAut:        "0842ea722ff6835bfa2032499fc3ec23c2f0e388b4f07543ffc677f1696d71ea",
+		kRe:         "cf83aa8bc7e0aced892acc98e76a9b2095b558c7795c7094715cb3393aa7d17a",
+		msk:         "67c42d9aa56c1b79e295e3459fc3d187d42be0bf818d3070e362c5e967a4d544e8ecfe19358ab3039aff03b7c930588c055babee58a02650b067ec4e9347c75a",
+		emsk:        "f861703cd775590e16c7679ea3874ada866311de290764d760cf76df647ea01c313f69924bdd7650ca9bac141ea075c4ef9e8029c0e290cdbad5638b63bc23fb",
+	},
+	{
+		name:        "test case 2",
+		identity:    "0555444333222111",
+		networkName: "HRPD",
+		autn:        "bb52e91c747ac3ab2a5c23d15ee351d5",
+		ik:          "9744871ad32bf9bbd1dd5ce54e3e2e5a",
+		ck:          "5349fbe098649f948f5d2e973a81c00f",
+		ckPrime:     "3820f0277fa5f77732b1fb1d90c1a0da",
+		ikPrime:     "db94a0ab557ef6c9ab48619ca05b9a9f",
+		kEncr:       "05ad73ac915fce89ac77e1520d82187b",
+		kAut:        "5b4acaef62c6ebb8882b2f3d534c4b35277337a00184f20ff25d224c04be2afd",
+		kRe:         "3f90bf5c6e5ef325ff04eb5ef6539fa8cca8398194fbd00be425b3f40dba10ac",
+		msk:         "87b321570117cd6c95ab6c436fb5073ff15cf85505d2bc5bb7355fc21ea8a75757e8f86a2b138002e05752913bb43b82f868a96117e91a2d95f526677d572900",
+		emsk:        "c891d5f20f148a1007553e2dea555c9cb672e9675f4a66b4bafa027379f93aee539a5979d0a0042b9d2ae28bed3b17a31dc8ab75072b80bd0c1da612466e402c",
+	},
+}
+
+func TestDeriveEapAkaPrime(t *testing.T) {
+	for _, tc := range eapAkaPrimeTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			autn := mustHex(t, tc.autn)
+			keys, err := DeriveEapAkaPrime(mustHex(t, tc.ck), mustHex(t, tc.ik), tc.networkName, autn[:SqnLen], tc.identity)
+			if err != nil {
+				t.Fatalf("DeriveEapAkaPrime failed: %v", err)
+			}
+			for _, c := range []struct {
+				name string
+				got  []byte
+				want string
+			}{
+				{"CK'", keys.CkPrime, tc.ckPrime},
+				{"IK'", keys.IkPrime, tc.ikPrime},
+				{"K_encr", keys.KEncr, tc.kEncr},
+				{"K_aut", keys.KAut, tc.kAut},
+				{"K_re", keys.KRe, tc.kRe},
+				{"MSK", keys.Msk, tc.msk},
+				{"EMSK", keys.Emsk, tc.emsk},
+			} {
+				if hex.EncodeToString(c.got) != c.want {
+					t.Errorf("%s = %x, want %s", c.name, c.got, c.want)
+				}
+			}
+			if len(keys.Mk) != MkLen {
+				t.Errorf("MK is %d octets, want %d", len(keys.Mk), MkLen)
+			}
+		})
+	}
+}
+
+func TestGenerateEapAkaPrime(t *testing.T) {
+	m, err := milenage.New(mustHex(t, testK), mustHex(t, testOp))
+	if err != nil {
+		t.Fatalf("milenage.New failed: %v", err)
+	}
+	rand, sqn, amf := mustHex(t, testRand), mustHex(t, testSqn), mustHex(t, testAmf)
+
+	autn, xres, keys, err := GenerateEapAkaPrime(m, rand, sqn, amf, "WLAN", "0208930000000001")
+	if err != nil {
+		t.Fatalf("GenerateEapAkaPrime failed: %v", err)
+	}
+	if hex.EncodeToString(xres) != testRes {
+		t.Fatalf("XRES = %x, want %s", xres, testRes)
+	}
+	want, err := DeriveEapAkaPrime(mustHex(t, testCk), mustHex(t, testIk), "WLAN", autn[:SqnLen], "0208930000000001")
+	if err != nil {
+		t.Fatalf("DeriveEapAkaPrime failed: %v", err)
+	}
+	if !bytes.Equal(keys.Mk, want.Mk) {
+		t.Fatalf("MK = %x, want %x", keys.Mk, want.Mk)
+	}
+
+	if _, _, _, err := GenerateEapAkaPrime(m, rand, sqn, amf, "", "0208930000000001"); err == nil {
+		t.Error("GenerateEapAkaPrime accepted an empty network name")
+	}
+}
@@ -0,0 +1,49 @@
+// aka/kdf.go
+//
+// Package aka derives the authentication vectors and keys of 5G-AKA (TS 33.501)
+// from the output of a subscriber's authentication functions f1-f5.
+package aka
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// FC values of the key derivations (TS 33.501 Annex A)
+const (
+	FcKausf5GAka = 0x6A
+	FcResStar    = 0x6B
+	FcKseaf      = 0x6C
+)
+
+// KDF is the generic key derivation function of TS 33.220 Annex B.2:
+// HMAC-SHA-256(key, FC || P0 || L0 || P1 || L1 || ...)
+func KDF(key []byte, fc byte, params ...[]byte) []byte {
+	s := []byte{fc}
+	for _, p := range params {
+		s = append(s, p...)
+		s = append(s, byte(len(p)>>8), byte(len(p)))
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(s)
+	return mac.Sum(nil)
+}
+
+// ServingNetworkName builds the serving network name "5G:mnc<MNC>.mcc<MCC>.3gppnetwork.org"
+// of TS 24.501 clause 9.12.1, with the MNC padded to three digits
+func ServingNetworkName(mcc, mnc string) string {
+	if len(mnc) == 2 {
+		mnc = "0" + mnc
+	}
+	return fmt.Sprintf("5G:mnc%s.mcc%s.3gppnetwork.org", mnc, mcc)
+}
+
+// ValidateServingNetworkName checks that a serving network name carries the "5G:" service code
+func ValidateServingNetworkName(name string) error {
+	if !strings.HasPrefix(name, "5G:") || len(name) == len("5G:") {
+		return fmt.Errorf("invalid serving network name %q: must be 5G:<network identifier>", name)
+	}
+	return nil
+}
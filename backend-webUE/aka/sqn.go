@@ -0,0 +1,103 @@
+// aka/sqn.go
+package aka
+
+import (
+	"crypto/hmac"
+	"fmt"
+)
+
+// IndLen is the length in bits of the IND part of SQN = SEQ || IND (TS 33.102 Annex C.3.2)
+const IndLen = 5
+
+// MaxSqn is the largest 48-bit sequence number
+const MaxSqn = 1<<48 - 1
+
+// SqnToUint64 converts a 6 octet SQN to an integer
+func SqnToUint64(sqn []byte) (uint64, error) {
+	if len(sqn) != SqnLen {
+		return 0, fmt.Errorf("SQN must be %d octets, got %d", SqnLen, len(sqn))
+	}
+	var v uint64
+	for _, b := range sqn {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// Uint64ToSqn converts an integer to a 6 octet SQN
+func Uint64ToSqn(v uint64) []byte {
+	sqn := make([]byte, SqnLen)
+	for i := SqnLen - 1; i >= 0; i-- {
+		sqn[i] = byte(v)
+		v >>= 8
+	}
+	return sqn
+}
+
+// NextSqn returns the SQN of the next authentication vector: SEQ is incremented and IND
+// moves to the next slot of the UE's array, so vectors used out of order are still accepted
+func NextSqn(sqn uint64) uint64 {
+	const indMask = 1<<IndLen - 1
+	seq := sqn >> IndLen
+	ind := sqn & indMask
+	seq++
+	ind = (ind + 1) & indMask
+	return (seq<<IndLen | ind) & MaxSqn
+}
+
+// ResyncAlgorithm is the set of authentication functions needed for resynchronisation
+type ResyncAlgorithm interface {
+	// F1Star computes MAC-S
+	F1Star(rand, sqn, amf []byte) ([]byte, error)
+	// F5Star computes AK for resynchronisation
+	F5Star(rand []byte) ([]byte, error)
+}
+
+// resyncAmf is the dummy AMF used in MAC-S (TS 33.102 clause 6.3.3)
+var resyncAmf = []byte{0x00, 0x00}
+
+// GenerateAuts computes AUTS = SQN_MS xor AK* || MAC-S, as a UE does when it rejects SQN
+func GenerateAuts(alg ResyncAlgorithm, rand, sqnMs []byte) ([]byte, error) {
+	if len(sqnMs) != SqnLen {
+		return nil, fmt.Errorf("SQN_MS must be %d octets, got %d", SqnLen, len(sqnMs))
+	}
+	akStar, err := alg.F5Star(rand)
+	if err != nil {
+		return nil, err
+	}
+	macS, err := alg.F1Star(rand, sqnMs, resyncAmf)
+	if err != nil {
+		return nil, err
+	}
+
+	auts := make([]byte, SqnLen, SqnLen+len(macS))
+	for i := range auts {
+		auts[i] = sqnMs[i] ^ akStar[i]
+	}
+	return append(auts, macS...), nil
+}
+
+// Resynchronise recovers SQN_MS from AUTS and the RAND that triggered it and verifies MAC-S
+// (TS 33.102 clause 6.3.5)
+func Resynchronise(alg ResyncAlgorithm, rand, auts []byte) ([]byte, error) {
+	if len(auts) <= SqnLen {
+		return nil, fmt.Errorf("AUTS is too short: %d octets", len(auts))
+	}
+	akStar, err := alg.F5Star(rand)
+	if err != nil {
+		return nil, err
+	}
+
+	sqnMs := make([]byte, SqnLen)
+	for i := range sqnMs {
+		sqnMs[i] = auts[i] ^ akStar[i]
+	}
+	macS, err := alg.F1Star(rand, sqnMs, resyncAmf)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(macS, auts[SqnLen:]) {
+		return nil, fmt.Errorf("MAC-S verification failed")
+	}
+	return sqnMs, nil
+}
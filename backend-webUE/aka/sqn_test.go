@@ -0,0 +1,72 @@
+package aka
+
+import (
+	"backend-webUE/milenage"
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSqnConversion(t *testing.T) {
+	sqn := mustHex(t, testSqn)
+	v, err := SqnToUint64(sqn)
+	if err != nil {
+		t.Fatalf("SqnToUint64 failed: %v", err)
+	}
+	if v != 0xff9bb4d0b607 {
+		t.Fatalf("SqnToUint64 = %x, want ff9bb4d0b607", v)
+	}
+	if got := Uint64ToSqn(v); !bytes.Equal(got, sqn) {
+		t.Fatalf("Uint64ToSqn = %x, want %x", got, sqn)
+	}
+	if _, err := SqnToUint64(sqn[:5]); err == nil {
+		t.Fatal("SqnToUint64 accepted a short SQN")
+	}
+}
+
+func TestNextSqn(t *testing.T) {
+	cases := []struct{ sqn, want uint64 }{
+		{0, 0x21},    // SEQ 0 -> 1, IND 0 -> 1
+		{0x21, 0x42}, // SEQ 1 -> 2, IND 1 -> 2
+		{0x3f, 0x40}, // IND 31 wraps to 0
+		{MaxSqn, 0},  // SEQ and IND both wrap around
+	}
+	for _, c := range cases {
+		if got := NextSqn(c.sqn); got != c.want {
+			t.Errorf("NextSqn(%x) = %x, want %x", c.sqn, got, c.want)
+		}
+	}
+}
+
+func TestResynchronise(t *testing.T) {
+	m, err := milenage.New(mustHex(t, testK), mustHex(t, testOp))
+	if err != nil {
+		t.Fatalf("milenage.New failed: %v", err)
+	}
+	rand, sqnMs := mustHex(t, testRand), mustHex(t, testSqn)
+
+	auts, err := GenerateAuts(m, rand, sqnMs)
+	if err != nil {
+		t.Fatalf("GenerateAuts failed: %v", err)
+	}
+	// TS 35.208 test set 1: AK* = 451e8beca43b, MAC-S = f1*(SQN, RAND, AMF = 0000)
+	if got := hex.EncodeToString(auts[:SqnLen]); got != "ba853f3c123c" {
+		t.Fatalf("SQN_MS xor AK* = %s, want ba853f3c123c", got)
+	}
+
+	recovered, err := Resynchronise(m, rand, auts)
+	if err != nil {
+		t.Fatalf("Resynchronise failed: %v", err)
+	}
+	if !bytes.Equal(recovered, sqnMs) {
+		t.Fatalf("SQN_MS = %x, want %x", recovered, sqnMs)
+	}
+
+	auts[len(auts)-1] ^= 0x01
+	if _, err := Resynchronise(m, rand, auts); err == nil {
+		t.Fatal("Resynchronise accepted a tampered MAC-S")
+	}
+	if _, err := Resynchronise(m, rand, auts[:SqnLen]); err == nil {
+		t.Fatal("Resynchronise accepted a truncated AUTS")
+	}
+}
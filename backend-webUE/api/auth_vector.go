@@ -0,0 +1,60 @@
+// api/auth_vector.go
+package api
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/services"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthVectorAPI serves the authentication vectors and SQN resynchronisation of stored UE Profiles
+type AuthVectorAPI struct {
+	ueProfileService *services.UeProfileService
+}
+
+// NewAuthVectorAPI creates a new AuthVectorAPI
+func NewAuthVectorAPI(ueProfileService *services.UeProfileService) *AuthVectorAPI {
+	return &AuthVectorAPI{ueProfileService: ueProfileService}
+}
+
+// RegisterRoutes registers the authentication vector routes
+func (a *AuthVectorAPI) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/ue_profiles/:supi/auth-vectors", a.GenerateAuthVector)
+	router.POST("/ue_profiles/:supi/resync", a.Resynchronise)
+}
+
+// GenerateAuthVector computes a 5G-AKA authentication vector of a UE Profile. Every field of the
+// request is optional, so an empty body takes the defaults.
+func (a *AuthVectorAPI) GenerateAuthVector(c *gin.Context) {
+	var req models.AuthVectorRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	av, err := a.ueProfileService.GenerateAuthVector(c.Param("supi"), req)
+	if err != nil {
+		c.JSON(serviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, av)
+}
+
+// Resynchronise moves the SQN of a UE Profile to the one recovered from the AUTS a UE returned
+func (a *AuthVectorAPI) Resynchronise(c *gin.Context) {
+	var req models.ResyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	state, err := a.ueProfileService.Resynchronise(c.Param("supi"), req)
+	if err != nil {
+		c.JSON(serviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
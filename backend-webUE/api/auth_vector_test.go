@@ -0,0 +1,48 @@
+package api
+
+import (
+	"backend-webUE/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestAuthVectorRoutesRejectMalformedBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewAuthVectorAPI(nil).RegisterRoutes(router.Group("/"))
+
+	for _, route := range []struct{ path, body string }{
+		{"/ue_profiles/imsi-208930000000001/auth-vectors", "{"},
+		{"/ue_profiles/imsi-208930000000001/auth-vectors", `{"rand": 5}`},
+		{"/ue_profiles/imsi-208930000000001/resync", ""},
+		{"/ue_profiles/imsi-208930000000001/resync", `{"auts": []}`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, route.path, strings.NewReader(route.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"error"`) {
+			t.Errorf("%s with body %q: status %d, response %s, want 400 with an error", route.path, route.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestServiceErrorStatus(t *testing.T) {
+	for err, want := range map[error]int{
+		&services.RequestError{Err: errors.New("invalid RAND")}: http.StatusBadRequest,
+		fmt.Errorf("SUPI: %w", mongo.ErrNoDocuments):            http.StatusNotFound,
+		mongo.ErrNoDocuments:                                    http.StatusNotFound,
+		errors.New("server selection timeout"):                  http.StatusInternalServerError,
+	} {
+		if got := serviceErrorStatus(err); got != want {
+			t.Errorf("serviceErrorStatus(%v) = %d, want %d", err, got, want)
+		}
+	}
+}
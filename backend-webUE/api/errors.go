@@ -0,0 +1,24 @@
+// api/errors.go
+package api
+
+import (
+	"backend-webUE/services"
+	"errors"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// serviceErrorStatus returns the HTTP status of a service error: 400 for invalid input,
+// 404 for a missing document and 500 otherwise
+func serviceErrorStatus(err error) int {
+	var requestErr *services.RequestError
+	switch {
+	case errors.As(err, &requestErr):
+		return http.StatusBadRequest
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
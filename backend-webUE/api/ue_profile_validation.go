@@ -0,0 +1,36 @@
+// api/ue_profile_validation.go
+package api
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UeProfileValidationAPI validates UE Profiles as a dry run, without writing them
+type UeProfileValidationAPI struct {
+	ueProfileService *services.UeProfileService
+}
+
+// NewUeProfileValidationAPI creates a new UeProfileValidationAPI
+func NewUeProfileValidationAPI(ueProfileService *services.UeProfileService) *UeProfileValidationAPI {
+	return &UeProfileValidationAPI{ueProfileService: ueProfileService}
+}
+
+// RegisterRoutes registers the UE Profile validation route
+func (a *UeProfileValidationAPI) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/ue_profiles/validate", a.ValidateUeProfile)
+}
+
+// ValidateUeProfile runs the checks of every UE Profile write and reports each invalid field.
+// An invalid profile is a successful validation, answered with 200 and valid set to false.
+func (a *UeProfileValidationAPI) ValidateUeProfile(c *gin.Context) {
+	var ue models.UeProfile
+	if err := c.ShouldBindJSON(&ue); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, a.ueProfileService.ValidateUeProfile(&ue))
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateUeProfileRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewUeProfileValidationAPI(&services.UeProfileService{}).RegisterRoutes(router.Group("/"))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/ue_profiles/validate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"supi": "imsi-20893000000001", "plmnid": {"mcc": "208", "mnc": "9"}}`)
+	var report models.ValidationReport
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &report) != nil {
+		t.Fatalf("status %d, response %s, want 200 with a report", w.Code, w.Body.String())
+	}
+	if report.Valid || len(report.Errors) == 0 {
+		t.Fatalf("report = %+v, want field errors", report)
+	}
+	found := false
+	for _, fieldError := range report.Errors {
+		found = found || fieldError.Field == "plmnid.mnc"
+	}
+	if !found {
+		t.Fatalf("report = %+v, want an error of plmnid.mnc", report)
+	}
+
+	if w := post("{"); w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: status %d, want 400", w.Code)
+	}
+}
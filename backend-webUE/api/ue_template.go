@@ -0,0 +1,137 @@
+// api/ue_template.go
+package api
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/services"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// generatePath is the UE Profile generation route, which also generates from templates
+const generatePath = "/ue_profiles/generate"
+
+// UeTemplateAPI serves the named UE generation templates and the batches generated from them
+type UeTemplateAPI struct {
+	ueTemplateService *services.UeTemplateService
+}
+
+// NewUeTemplateAPI creates a new UeTemplateAPI
+func NewUeTemplateAPI(ueTemplateService *services.UeTemplateService) *UeTemplateAPI {
+	return &UeTemplateAPI{ueTemplateService: ueTemplateService}
+}
+
+// RegisterRoutes registers the UE template routes. Generation from a template is served on
+// POST /ue_profiles/generate by the TemplateGeneration middleware.
+func (a *UeTemplateAPI) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/ue_templates", a.CreateTemplate)
+	router.GET("/ue_templates", a.GetAllTemplates)
+	router.GET("/ue_templates/:id", a.GetTemplate)
+	router.PUT("/ue_templates/:id", a.UpdateTemplate)
+	router.DELETE("/ue_templates/:id", a.DeleteTemplate)
+}
+
+// CreateTemplate stores a new template and returns it with its ID
+func (a *UeTemplateAPI) CreateTemplate(c *gin.Context) {
+	var template models.UeTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if err := a.ueTemplateService.CreateTemplate(&template); err != nil {
+		c.JSON(serviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetAllTemplates lists the templates sorted by name
+func (a *UeTemplateAPI) GetAllTemplates(c *gin.Context) {
+	templates, err := a.ueTemplateService.GetAllTemplates()
+	if err != nil {
+		c.JSON(serviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if templates == nil {
+		templates = []models.UeTemplate{}
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate returns a template by its ID
+func (a *UeTemplateAPI) GetTemplate(c *gin.Context) {
+	template, err := a.ueTemplateService.GetTemplate(c.Param("id"))
+	if err != nil {
+		c.JSON(serviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateTemplate replaces the definition of a template
+func (a *UeTemplateAPI) UpdateTemplate(c *gin.Context) {
+	var template models.UeTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if err := a.ueTemplateService.UpdateTemplate(c.Param("id"), &template); err != nil {
+		c.JSON(serviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate deletes a template; the UE Profiles generated from it are kept
+func (a *UeTemplateAPI) DeleteTemplate(c *gin.Context) {
+	if err := a.ueTemplateService.DeleteTemplate(c.Param("id")); err != nil {
+		c.JSON(serviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "UE template deleted"})
+}
+
+// TemplateGeneration returns the middleware that serves the POST /ue_profiles/generate requests
+// naming a templateId: it generates the batch from the template with the request's overrides and
+// reports how many UE Profiles were generated and which SUPIs were skipped as already in use.
+// Requests without a templateId go on to the UE Profile generation handler with their body intact.
+func (a *UeTemplateAPI) TemplateGeneration() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || c.FullPath() != generatePath {
+			c.Next()
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		var target struct {
+			TemplateId string `json:"templateId"`
+		}
+		if json.Unmarshal(body, &target) != nil || target.TemplateId == "" {
+			c.Next()
+			return
+		}
+
+		var req models.TemplateGenerateRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+		profiles, skipped, err := a.ueTemplateService.GenerateFromTemplate(req)
+		if err != nil {
+			c.AbortWithStatusJSON(serviceErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if skipped == nil {
+			skipped = []string{}
+		}
+		c.AbortWithStatusJSON(http.StatusCreated, gin.H{"generated": len(profiles), "skipped": skipped})
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUeTemplateRoutesRejectMalformedBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewUeTemplateAPI(nil).RegisterRoutes(router.Group("/"))
+
+	for _, route := range []struct{ method, path, body string }{
+		{http.MethodPost, "/ue_templates", "{"},
+		{http.MethodPut, "/ue_templates/65f000000000000000000001", `{"name": 1}`},
+	} {
+		req := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"error"`) {
+			t.Errorf("%s %s: status %d, response %s, want 400 with an error", route.method, route.path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestTemplateGenerationOnGenerateRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	protected := router.Group("/")
+	protected.Use(NewUeTemplateAPI(nil).TemplateGeneration())
+	// Stands in for the UE Profile generation handler
+	protected.POST(generatePath, func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusTeapot, "%s", body)
+	})
+
+	for _, tc := range []struct {
+		body   string
+		status int
+	}{
+		// Without a templateId the request reaches the generation handler unchanged
+		{`{"num_ues": 2, "plmnid": {"mcc": "208", "mnc": "93"}}`, http.StatusTeapot},
+		{`{`, http.StatusTeapot},
+		{`{"templateId": "65f000000000000000000001", "num_ues": "ten"}`, http.StatusBadRequest},
+		{`{"templateId": "65f000000000000000000001", "num_ues": 0}`, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, generatePath, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("POST %s %s: status %d, response %s, want %d", generatePath, tc.body, w.Code, w.Body.String(), tc.status)
+		}
+		if tc.status == http.StatusTeapot && w.Body.String() != tc.body {
+			t.Errorf("generation handler read %q, want %q", w.Body.String(), tc.body)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// cmd/auth-vectors/main.go
+//
+// auth-vectors computes the 5G-AKA authentication vector or the EAP-AKA' keys of a stored
+// UE Profile, the same values an AUSF/UDM would produce, for cross-checking a core.
+// Without -sqn the UE's stored SQN is advanced and used.
+//
+//	auth-vectors -supi imsi-208930000000001
+//	auth-vectors -supi imsi-208930000000001 -sqn 000000000021 -sn 5G:mnc093.mcc208.3gppnetwork.org -rand 23553cbe9637a89d218ae64dae47bf35
+//	auth-vectors -method eap-aka-prime -supi imsi-208930000000001 -sn WLAN
+//	auth-vectors -method sqn -supi imsi-208930000000001 [-sqn 000000000000]
+//	auth-vectors -method resync -supi imsi-208930000000001 -rand <RAND> -auts <AUTS>
+package main
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/services"
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	mongoURI := flag.String("mongo-uri", "mongodb://localhost:27017", "MongoDB connection URI")
+	dbName := flag.String("db", "webue_db", "MongoDB database name")
+	supi := flag.String("supi", "", "SUPI of the UE Profile")
+	sqn := flag.String("sqn", "", "hex 48-bit sequence number, the UE's next SQN when empty")
+	method := flag.String("method", "5g-aka", "5g-aka, eap-aka-prime, sqn (show or with -sqn set the stored SQN) or resync")
+	servingNetworkName := flag.String("sn", "", "serving network name (5G-AKA, defaults to the UE's home PLMN) or access network name (EAP-AKA')")
+	rand := flag.String("rand", "", "hex 128-bit RAND, random when empty")
+	identity := flag.String("identity", "", "EAP-AKA' peer identity, defaults to the SUPI without its type prefix")
+	auts := flag.String("auts", "", "hex AUTS returned by the UE, for resync")
+	flag.Parse()
+
+	if *supi == "" {
+		log.Fatalf("-supi is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(*mongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	ueProfileService := services.NewUeProfileService(client.Database(*dbName), nil)
+
+	var result interface{}
+	switch *method {
+	case "5g-aka":
+		result, err = ueProfileService.GenerateAuthVector(*supi, models.AuthVectorRequest{
+			ServingNetworkName: *servingNetworkName,
+			Sqn:                *sqn,
+			Rand:               *rand,
+		})
+	case "eap-aka-prime":
+		result, err = ueProfileService.GenerateEapAkaPrimeKeys(*supi, models.EapAkaPrimeRequest{
+			NetworkName: *servingNetworkName,
+			Sqn:         *sqn,
+			Rand:        *rand,
+			Identity:    *identity,
+		})
+	case "sqn":
+		if *sqn == "" {
+			result, err = ueProfileService.GetSqn(*supi)
+		} else {
+			result, err = ueProfileService.SetSqn(*supi, *sqn)
+		}
+	case "resync":
+		result, err = ueProfileService.Resynchronise(*supi, models.ResyncRequest{
+			Rand: *rand,
+			Auts: *auts,
+		})
+	default:
+		log.Fatalf("unknown method: %s", *method)
+	}
+	if err != nil {
+		log.Fatalf("Failed to generate the %s keys of %s: %v", *method, *supi, err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+}
@@ -0,0 +1,134 @@
+// cmd/hn-keys/main.go
+//
+// hn-keys manages the home network key registry:
+//
+//	hn-keys list
+//	hn-keys create -scheme 1
+//	hn-keys import -scheme 2 -key-id 2 -private-key <hex>
+//	hn-keys retire -key-id 3
+//	hn-keys rotate -scheme 1
+//	hn-keys migrate -output output
+package main
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/services"
+	"backend-webUE/utils"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatalf("usage: %s list|create|import|retire|rotate|migrate [flags]", os.Args[0])
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	mongoURI := flags.String("mongo-uri", "mongodb://localhost:27017", "MongoDB connection URI")
+	dbName := flags.String("db", "webue_db", "MongoDB database name")
+	scheme := flags.Int("scheme", 0, "protection scheme (1 = Profile A, 2 = Profile B)")
+	keyId := flags.Int("key-id", 0, "home network public key ID (1-255, 0 assigns the next free ID)")
+	privateKey := flags.String("private-key", "", "hex private key to import")
+	outputDir := flags.String("output", "output", "directory holding the exported UE Profile YAML files")
+	flags.Parse(os.Args[2:])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(*mongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	db := client.Database(*dbName)
+	ueProfileService := services.NewUeProfileService(db, nil)
+	keyService := services.NewHomeNetworkKeyService(db, ueProfileService)
+
+	switch command {
+	case "list":
+		keys, err := keyService.GetAllKeys()
+		if err != nil {
+			log.Fatalf("Failed to list home network keys: %v", err)
+		}
+		for _, key := range keys {
+			fmt.Printf("%3d  scheme %d  %-7s  %s\n", key.KeyId, key.Scheme, key.Status, key.PublicKey)
+		}
+	case "create":
+		key, err := keyService.CreateKey(*scheme)
+		if err != nil {
+			log.Fatalf("Failed to create home network key: %v", err)
+		}
+		fmt.Printf("Created key ID %d: %s\n", key.KeyId, key.PublicKey)
+	case "import":
+		key, err := keyService.ImportKey(*scheme, *keyId, *privateKey)
+		if err != nil {
+			log.Fatalf("Failed to import home network key: %v", err)
+		}
+		fmt.Printf("Imported key ID %d: %s\n", key.KeyId, key.PublicKey)
+	case "retire":
+		if err := keyService.RetireKey(*keyId); err != nil {
+			log.Fatalf("Failed to retire home network key %d: %v", *keyId, err)
+		}
+		fmt.Printf("Retired key ID %d\n", *keyId)
+	case "rotate":
+		key, moved, err := keyService.RotateKey(*scheme)
+		if err != nil {
+			log.Fatalf("Failed to rotate home network key: %v", err)
+		}
+		fmt.Printf("Rotated scheme %d to key ID %d, %d UE Profile(s) updated\n", *scheme, key.KeyId, moved)
+	case "migrate":
+		imported, stripped, err := keyService.MigrateUeProfileKeys()
+		if err != nil {
+			log.Fatalf("Failed to migrate home network private keys: %v", err)
+		}
+		fmt.Printf("Imported %d key(s), removed the private key from %d UE Profile(s)\n", imported, stripped)
+
+		count, err := stripYAMLPrivateKeys(*outputDir)
+		if err != nil {
+			log.Fatalf("Failed to remove home network private keys from %s: %v", *outputDir, err)
+		}
+		fmt.Printf("Rewrote %d UE Profile(s) in %s\n", count, *outputDir)
+	default:
+		log.Fatalf("unknown command: %s", command)
+	}
+}
+
+// stripYAMLPrivateKeys rewrites every exported UE Profile in dir without its home network private key
+func stripYAMLPrivateKeys(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, file := range files {
+		var legacy struct {
+			PrivateKey string `yaml:"homenetworkprivatekey"`
+		}
+		if err := utils.ImportYAML(file, &legacy); err != nil {
+			return count, err
+		}
+		if legacy.PrivateKey == "" {
+			continue
+		}
+		var ue models.UeProfile
+		if err := utils.ImportYAML(file, &ue); err != nil {
+			return count, err
+		}
+		// UE Profiles are encoded without the private key
+		if err := utils.ExportYAML(file, ue); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,82 @@
+// cmd/master-key/main.go
+//
+// master-key manages the master key file that encrypts K, OP and home network private keys at rest:
+//
+//	master-key generate -file master.key     # adds a new master key version, the new active one
+//	master-key reencrypt -file master.key    # re-encrypts every stored secret with the active version
+//
+// Rotating the master key is generate followed by reencrypt; older versions stay in the file
+// until no stored value references them.
+package main
+
+import (
+	"backend-webUE/secrets"
+	"backend-webUE/services"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatalf("usage: %s generate|reencrypt [flags]", os.Args[0])
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	mongoURI := flags.String("mongo-uri", "mongodb://localhost:27017", "MongoDB connection URI")
+	dbName := flags.String("db", "webue_db", "MongoDB database name")
+	file := flags.String("file", os.Getenv(secrets.MasterKeyFileEnv), "master key file (defaults to $"+secrets.MasterKeyFileEnv+")")
+	flags.Parse(os.Args[2:])
+
+	if *file == "" {
+		log.Fatalf("-file or %s is required", secrets.MasterKeyFileEnv)
+	}
+
+	switch command {
+	case "generate":
+		version, err := secrets.AddMasterKey(*file)
+		if err != nil {
+			log.Fatalf("Failed to generate master key: %v", err)
+		}
+		fmt.Printf("Added master key version %d to %s\n", version, *file)
+	case "reencrypt":
+		keyring, err := secrets.LoadKeyring(*file)
+		if err != nil {
+			log.Fatalf("Failed to load the master key: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(*mongoURI))
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer client.Disconnect(context.Background())
+
+		db := client.Database(*dbName)
+		ueProfileService := services.NewUeProfileService(db, nil)
+		ueProfileService.SetKeyring(keyring)
+		keyService := services.NewHomeNetworkKeyService(db, ueProfileService)
+		keyService.SetKeyring(keyring)
+
+		profiles, err := ueProfileService.ReencryptSecrets()
+		if err != nil {
+			log.Fatalf("Failed to re-encrypt UE Profiles: %v", err)
+		}
+		keys, err := keyService.ReencryptPrivateKeys()
+		if err != nil {
+			log.Fatalf("Failed to re-encrypt home network keys: %v", err)
+		}
+		fmt.Printf("Re-encrypted %d UE Profile(s) and %d home network key(s) with master key version %d\n",
+			profiles, keys, keyring.ActiveVersion())
+	default:
+		log.Fatalf("unknown command: %s", command)
+	}
+}
@@ -0,0 +1,86 @@
+// cmd/opc/main.go
+//
+// opc prints the OPc of UE Profiles, or the TOPc of TUAK profiles, for cores that are
+// only provisioned with OPc/TOPc:
+//
+//	opc -supi imsi-208930000000001
+//	opc -all
+//	opc -k 465b5ce8b199b49faa5f0a2ee238a6bc -op cdc202d5123e20f62b6d676ac72cb318
+//	opc -k abababababababababababababababab -top 5555555555555555555555555555555555555555555555555555555555555555
+package main
+
+import (
+	"backend-webUE/milenage"
+	"backend-webUE/services"
+	"backend-webUE/tuak"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	mongoURI := flag.String("mongo-uri", "mongodb://localhost:27017", "MongoDB connection URI")
+	dbName := flag.String("db", "webue_db", "MongoDB database name")
+	supi := flag.String("supi", "", "SUPI of the UE Profile")
+	all := flag.Bool("all", false, "print the OPc of every UE Profile")
+	k := flag.String("k", "", "hex subscriber key K, derives OPc offline together with -op, or TOPc with -top")
+	op := flag.String("op", "", "hex Milenage operator variant OP")
+	top := flag.String("top", "", "hex TUAK operator variant TOP")
+	flag.Parse()
+
+	if *top != "" {
+		topc, err := tuak.GenerateTopcHex(*k, *top)
+		if err != nil {
+			log.Fatalf("Failed to derive TOPc: %v", err)
+		}
+		fmt.Println(topc)
+		return
+	}
+	if *k != "" || *op != "" {
+		opc, err := milenage.GenerateOpcHex(*k, *op)
+		if err != nil {
+			log.Fatalf("Failed to derive OPc: %v", err)
+		}
+		fmt.Println(opc)
+		return
+	}
+	if *supi == "" && !*all {
+		log.Fatalf("one of -supi, -all or -k/-op is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(*mongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	ueProfileService := services.NewUeProfileService(client.Database(*dbName), nil)
+
+	if !*all {
+		opc, err := ueProfileService.GetOpc(*supi)
+		if err != nil {
+			log.Fatalf("Failed to get OPc of %s: %v", *supi, err)
+		}
+		fmt.Println(opc)
+		return
+	}
+
+	profiles, err := ueProfileService.GetAllUEProfiles()
+	if err != nil {
+		log.Fatalf("Failed to list UE Profiles: %v", err)
+	}
+	for i := range profiles {
+		opc, err := services.ProfileOpc(&profiles[i])
+		if err != nil {
+			log.Fatalf("Failed to get OPc of %s: %v", profiles[i].Supi, err)
+		}
+		fmt.Printf("%s %s\n", profiles[i].Supi, opc)
+	}
+}
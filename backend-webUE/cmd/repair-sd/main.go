@@ -0,0 +1,81 @@
+// cmd/repair-sd/main.go
+//
+// repair-sd rewrites the slice differentiators of every UE Profile in the ue_profiles
+// collection and in the YAML exports in their stored form, 6 lowercase hex digits, so
+// that session slices written as "0x010203" match the NSSAI entries written as "010203".
+package main
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/services"
+	"backend-webUE/utils"
+	"context"
+	"flag"
+	"log"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	mongoURI := flag.String("mongo-uri", "mongodb://localhost:27017", "MongoDB connection URI")
+	dbName := flag.String("db", "webue_db", "MongoDB database name")
+	outputDir := flag.String("output", "output", "directory holding the exported UE Profile YAML files")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(*mongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	// Only slices are rewritten, so K and OP stay as stored and no master key is needed
+	ueProfileService := services.NewUeProfileService(client.Database(*dbName), nil)
+	repaired, err := ueProfileService.RepairSds()
+	if err != nil {
+		log.Fatalf("Failed to repair SDs in the database: %v", err)
+	}
+	log.Printf("Repaired the SDs of %d UE Profile(s) in the ue_profiles collection", len(repaired))
+
+	count, err := repairYAMLSds(*outputDir)
+	if err != nil {
+		log.Fatalf("Failed to repair SDs in %s: %v", *outputDir, err)
+	}
+	log.Printf("Repaired the SDs of %d UE Profile(s) in %s", count, *outputDir)
+}
+
+// repairYAMLSds rewrites the exported UE Profiles in dir whose SDs are not in their stored form
+func repairYAMLSds(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, file := range files {
+		var ue models.UeProfile
+		if err := utils.ImportYAML(file, &ue); err != nil {
+			return count, err
+		}
+
+		normalized := ue
+		if err := utils.NormalizeUeSds(&normalized); err != nil {
+			log.Printf("Skipping %s: %v", file, err)
+			continue
+		}
+		if reflect.DeepEqual(normalized, ue) {
+			continue
+		}
+
+		if err := utils.ExportYAML(file, normalized); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,79 @@
+// cmd/repair-suci/main.go
+//
+// repair-suci recomputes the SUCI of every UE Profile in the ue_profiles collection
+// and in the YAML exports, replacing SUCIs written with MNC before MCC and the full
+// home network public key in place of its key identifier.
+package main
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/services"
+	"backend-webUE/utils"
+	"context"
+	"flag"
+	"log"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	mongoURI := flag.String("mongo-uri", "mongodb://localhost:27017", "MongoDB connection URI")
+	dbName := flag.String("db", "webue_db", "MongoDB database name")
+	outputDir := flag.String("output", "output", "directory holding the exported UE Profile YAML files")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(*mongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	ueProfileService := services.NewUeProfileService(client.Database(*dbName), nil)
+	repaired, err := ueProfileService.RepairSucis()
+	if err != nil {
+		log.Fatalf("Failed to repair SUCIs in the database: %v", err)
+	}
+	log.Printf("Repaired %d SUCI(s) in the ue_profiles collection", len(repaired))
+
+	count, err := repairYAMLSucis(*outputDir, repaired)
+	if err != nil {
+		log.Fatalf("Failed to repair SUCIs in %s: %v", *outputDir, err)
+	}
+	log.Printf("Repaired %d SUCI(s) in %s", count, *outputDir)
+}
+
+// repairYAMLSucis rewrites the SUCI of every exported UE Profile in dir. Profiles that
+// were repaired in the database get the same SUCI, the others get a fresh one.
+func repairYAMLSucis(dir string, repaired map[string]string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, file := range files {
+		var ue models.UeProfile
+		if err := utils.ImportYAML(file, &ue); err != nil {
+			return count, err
+		}
+
+		suci, ok := repaired[ue.Supi]
+		if !ok {
+			if suci, err = utils.RecomputeSuci(&ue); err != nil {
+				return count, err
+			}
+		}
+		ue.Suci = suci
+
+		if err := utils.ExportYAML(file, ue); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,211 @@
+// milenage/milenage.go
+//
+// Package milenage implements the 3GPP MILENAGE authentication and key generation
+// functions f1, f1*, f2, f3, f4, f5 and f5* (TS 35.205/35.206) and the derivation
+// of OPc from the operator variant algorithm configuration field OP.
+package milenage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	KeyLen  = 16 // octets, K, OP and OPc
+	RandLen = 16 // octets
+	SqnLen  = 6  // octets
+	AmfLen  = 2  // octets
+	MacLen  = 8  // octets, MAC-A and MAC-S
+	ResLen  = 8  // octets
+	CkLen   = 16 // octets
+	IkLen   = 16 // octets
+	AkLen   = 6  // octets
+)
+
+// Rotation amounts r1..r5 in octets and the last octet of the constants c1..c5 (TS 35.206 clause 4.1)
+var (
+	rotations = [5]int{8, 0, 4, 8, 12}
+	constants = [5]byte{0x00, 0x01, 0x02, 0x04, 0x08}
+)
+
+// Milenage holds a subscriber key K and its OPc
+type Milenage struct {
+	opc   []byte
+	block cipher.Block
+}
+
+// New creates a Milenage instance from K and OP, deriving OPc
+func New(k, op []byte) (*Milenage, error) {
+	opc, err := GenerateOpc(k, op)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithOpc(k, opc)
+}
+
+// NewWithOpc creates a Milenage instance from K and OPc
+func NewWithOpc(k, opc []byte) (*Milenage, error) {
+	if len(k) != KeyLen {
+		return nil, fmt.Errorf("K must be %d octets, got %d", KeyLen, len(k))
+	}
+	if len(opc) != KeyLen {
+		return nil, fmt.Errorf("OPc must be %d octets, got %d", KeyLen, len(opc))
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return &Milenage{opc: append([]byte(nil), opc...), block: block}, nil
+}
+
+// GenerateOpc derives OPc = E[OP]K xor OP
+func GenerateOpc(k, op []byte) ([]byte, error) {
+	if len(k) != KeyLen {
+		return nil, fmt.Errorf("K must be %d octets, got %d", KeyLen, len(k))
+	}
+	if len(op) != KeyLen {
+		return nil, fmt.Errorf("OP must be %d octets, got %d", KeyLen, len(op))
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	opc := make([]byte, KeyLen)
+	block.Encrypt(opc, op)
+	xor(opc, op)
+	return opc, nil
+}
+
+// GenerateOpcHex derives OPc from hex encoded K and OP and returns it hex encoded
+func GenerateOpcHex(kHex, opHex string) (string, error) {
+	k, err := hex.DecodeString(kHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid K: %v", err)
+	}
+	op, err := hex.DecodeString(opHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid OP: %v", err)
+	}
+	opc, err := GenerateOpc(k, op)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(opc), nil
+}
+
+// Opc returns the OPc in use
+func (m *Milenage) Opc() []byte {
+	return append([]byte(nil), m.opc...)
+}
+
+// F1 computes the network authentication code MAC-A
+func (m *Milenage) F1(rand, sqn, amf []byte) ([]byte, error) {
+	out, err := m.out1(rand, sqn, amf)
+	if err != nil {
+		return nil, err
+	}
+	return out[:MacLen], nil
+}
+
+// F1Star computes the resynchronisation authentication code MAC-S
+func (m *Milenage) F1Star(rand, sqn, amf []byte) ([]byte, error) {
+	out, err := m.out1(rand, sqn, amf)
+	if err != nil {
+		return nil, err
+	}
+	return out[MacLen:], nil
+}
+
+// F2345 computes the response RES (f2), the cipher key CK (f3), the integrity key IK (f4)
+// and the anonymity key AK (f5)
+func (m *Milenage) F2345(rand []byte) (res, ck, ik, ak []byte, err error) {
+	temp, err := m.temp(rand)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	out2 := m.out(temp, 1)
+	res = out2[8:16]
+	ak = out2[:AkLen]
+	ck = m.out(temp, 2)
+	ik = m.out(temp, 3)
+	return res, ck, ik, ak, nil
+}
+
+// F5Star computes the anonymity key AK used for resynchronisation
+func (m *Milenage) F5Star(rand []byte) ([]byte, error) {
+	temp, err := m.temp(rand)
+	if err != nil {
+		return nil, err
+	}
+	return m.out(temp, 4)[:AkLen], nil
+}
+
+// temp computes TEMP = E[RAND xor OPc]K
+func (m *Milenage) temp(rand []byte) ([]byte, error) {
+	if len(rand) != RandLen {
+		return nil, fmt.Errorf("RAND must be %d octets, got %d", RandLen, len(rand))
+	}
+	temp := make([]byte, KeyLen)
+	copy(temp, rand)
+	xor(temp, m.opc)
+	m.block.Encrypt(temp, temp)
+	return temp, nil
+}
+
+// out1 computes OUT1 from IN1 = SQN || AMF || SQN || AMF
+func (m *Milenage) out1(rand, sqn, amf []byte) ([]byte, error) {
+	if len(sqn) != SqnLen {
+		return nil, fmt.Errorf("SQN must be %d octets, got %d", SqnLen, len(sqn))
+	}
+	if len(amf) != AmfLen {
+		return nil, fmt.Errorf("AMF must be %d octets, got %d", AmfLen, len(amf))
+	}
+	temp, err := m.temp(rand)
+	if err != nil {
+		return nil, err
+	}
+
+	in1 := make([]byte, KeyLen)
+	copy(in1[0:], sqn)
+	copy(in1[6:], amf)
+	copy(in1[8:], sqn)
+	copy(in1[14:], amf)
+
+	xor(in1, m.opc)
+	block := rotate(in1, rotations[0])
+	block[KeyLen-1] ^= constants[0]
+	xor(block, temp)
+	m.block.Encrypt(block, block)
+	xor(block, m.opc)
+	return block, nil
+}
+
+// out computes OUTi = E[rot(TEMP xor OPc, ri) xor ci]K xor OPc for i = 2..5, indexed from 0
+func (m *Milenage) out(temp []byte, i int) []byte {
+	block := make([]byte, KeyLen)
+	copy(block, temp)
+	xor(block, m.opc)
+	block = rotate(block, rotations[i])
+	block[KeyLen-1] ^= constants[i]
+	m.block.Encrypt(block, block)
+	xor(block, m.opc)
+	return block
+}
+
+// rotate cyclically rotates a 128-bit block left by n octets
+func rotate(in []byte, n int) []byte {
+	out := make([]byte, len(in))
+	for i := range in {
+		out[i] = in[(i+n)%len(in)]
+	}
+	return out
+}
+
+// xor sets dst to dst xor src
+func xor(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
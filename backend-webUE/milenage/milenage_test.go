@@ -0,0 +1,147 @@
+package milenage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TS 35.208 clause 4.3 test sets
+var testSets = []struct {
+	name                               string
+	k, rand, sqn, amf, op, opc         string
+	f1, f1Star, f2, f3, f4, f5, f5Star string
+}{
+	{
+		name: "test set 1",
+		k:    "465b5ce8b199b49faa5f0a2ee238a6bc", rand: "23553cbe9637a89d218ae64dae47bf35",
+		sqn: "ff9bb4d0b607", amf: "b9b9",
+		op: "cdc202d5123e20f62b6d676ac72cb318", opc: "cd63cb71954a9f4e48a5994e37a02baf",
+		f1: "4a9ffac354dfafb3", f1Star: "01cfaf9ec4e871e9", f2: "a54211d5e3ba50bf",
+		f3: "b40ba9a3c58b2a05bbf0d987b21bf8cb", f4: "f769bcd751044604127672711c6d3441",
+		f5: "aa689c648370", f5Star: "451e8beca43b",
+	},
+	{
+		name: "test set 2",
+		k:    "0396eb317b6d1c36f19c1c84cd6ffd16", rand: "c00d603103dcee52c4478119494202e8",
+		sqn: "fd8eef40df7d", amf: "af17",
+		op: "ff53bade17df5d4e793073ce9d7579fa", opc: "53c15671c60a4b731c55b4a441c0bde2",
+		f1: "5df5b31807e258b0", f1Star: "a8c016e51ef4a343", f2: "d3a628ed988620f0",
+		f3: "58c433ff7a7082acd424220f2b67c556", f4: "21a8c1f929702adb3e738488b9f5c5da",
+		f5: "c47783995f72", f5Star: "30f1197061c1",
+	},
+	{
+		name: "test set 3",
+		k:    "fec86ba6eb707ed08905757b1bb44b8f", rand: "9f7c8d021accf4db213ccff0c7f71a6a",
+		sqn: "9d0277595ffc", amf: "725c",
+		op: "dbc59adcb6f9a0ef735477b7fadf8374", opc: "1006020f0a478bf6b699f15c062e42b3",
+		f1: "9cabc3e99baf7281", f1Star: "95814ba2b3044324", f2: "8011c48c0c214ed2",
+		f3: "5dbdbb2954e8f3cde665b046179a5098", f4: "59a92d3b476a0443487055cf88b2307b",
+		f5: "33484dc2136b", f5Star: "deacdd848cc6",
+	},
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+func checkHex(t *testing.T, name string, got []byte, want string) {
+	t.Helper()
+	if hex.EncodeToString(got) != want {
+		t.Errorf("%s = %x, want %s", name, got, want)
+	}
+}
+
+func TestMilenage(t *testing.T) {
+	for _, ts := range testSets {
+		t.Run(ts.name, func(t *testing.T) {
+			k, rand := mustHex(t, ts.k), mustHex(t, ts.rand)
+			sqn, amf := mustHex(t, ts.sqn), mustHex(t, ts.amf)
+
+			opc, err := GenerateOpc(k, mustHex(t, ts.op))
+			if err != nil {
+				t.Fatalf("GenerateOpc failed: %v", err)
+			}
+			checkHex(t, "OPc", opc, ts.opc)
+
+			m, err := New(k, mustHex(t, ts.op))
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			if !bytes.Equal(m.Opc(), opc) {
+				t.Fatalf("Opc() = %x, want %x", m.Opc(), opc)
+			}
+
+			macA, err := m.F1(rand, sqn, amf)
+			if err != nil {
+				t.Fatalf("F1 failed: %v", err)
+			}
+			checkHex(t, "f1", macA, ts.f1)
+			macS, err := m.F1Star(rand, sqn, amf)
+			if err != nil {
+				t.Fatalf("F1Star failed: %v", err)
+			}
+			checkHex(t, "f1*", macS, ts.f1Star)
+
+			res, ck, ik, ak, err := m.F2345(rand)
+			if err != nil {
+				t.Fatalf("F2345 failed: %v", err)
+			}
+			checkHex(t, "f2", res, ts.f2)
+			checkHex(t, "f3", ck, ts.f3)
+			checkHex(t, "f4", ik, ts.f4)
+			checkHex(t, "f5", ak, ts.f5)
+
+			akStar, err := m.F5Star(rand)
+			if err != nil {
+				t.Fatalf("F5Star failed: %v", err)
+			}
+			checkHex(t, "f5*", akStar, ts.f5Star)
+		})
+	}
+}
+
+func TestGenerateOpcHex(t *testing.T) {
+	opc, err := GenerateOpcHex(testSets[0].k, testSets[0].op)
+	if err != nil {
+		t.Fatalf("GenerateOpcHex failed: %v", err)
+	}
+	if opc != testSets[0].opc {
+		t.Fatalf("GenerateOpcHex = %s, want %s", opc, testSets[0].opc)
+	}
+
+	for _, in := range [][2]string{
+		{"465b5ce8b199b49faa5f0a2ee238a6", testSets[0].op},
+		{testSets[0].k, "cdc202d5123e20f62b6d676ac72cb3"},
+		{"zz5b5ce8b199b49faa5f0a2ee238a6bc", testSets[0].op},
+	} {
+		if _, err := GenerateOpcHex(in[0], in[1]); err == nil {
+			t.Errorf("GenerateOpcHex(%s, %s) accepted malformed input", in[0], in[1])
+		}
+	}
+}
+
+func TestInvalidLengths(t *testing.T) {
+	k := mustHex(t, testSets[0].k)
+	m, err := NewWithOpc(k, mustHex(t, testSets[0].opc))
+	if err != nil {
+		t.Fatalf("NewWithOpc failed: %v", err)
+	}
+	if _, err := NewWithOpc(k[:15], k); err == nil {
+		t.Error("NewWithOpc accepted a short K")
+	}
+	if _, err := m.F1(k[:15], make([]byte, SqnLen), make([]byte, AmfLen)); err == nil {
+		t.Error("F1 accepted a short RAND")
+	}
+	if _, err := m.F1(k, make([]byte, SqnLen-1), make([]byte, AmfLen)); err == nil {
+		t.Error("F1 accepted a short SQN")
+	}
+	if _, _, _, _, err := m.F2345(k[:15]); err == nil {
+		t.Error("F2345 accepted a short RAND")
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+// AuthVectorRequest selects the serving network and sequence number of a 5G-AKA authentication vector.
+// SQN is taken from the UE's sequence number state when empty and RAND is drawn at random when empty;
+// both are hex encoded.
+type AuthVectorRequest struct {
+	ServingNetworkName string `json:"servingNetworkName"`
+	Sqn                string `json:"sqn,omitempty"`
+	Rand               string `json:"rand,omitempty"`
+}
+
+// AuthVector is a 5G home environment authentication vector with the keys derived from it, hex encoded
+type AuthVector struct {
+	Supi               string `json:"supi"`
+	ServingNetworkName string `json:"servingNetworkName"`
+	Sqn                string `json:"sqn"`
+	Rand               string `json:"rand"`
+	Autn               string `json:"autn"`
+	XresStar           string `json:"xresStar"`
+	HxresStar          string `json:"hxresStar"`
+	Kausf              string `json:"kausf"`
+	Kseaf              string `json:"kseaf"`
+}
+
+// EapAkaPrimeRequest selects the access network name, sequence number and peer identity of an EAP-AKA'
+// key derivation. SQN and RAND are hex encoded and handled as in AuthVectorRequest.
+type EapAkaPrimeRequest struct {
+	NetworkName string `json:"networkName"`
+	Sqn         string `json:"sqn,omitempty"`
+	Rand        string `json:"rand,omitempty"`
+	Identity    string `json:"identity,omitempty"`
+}
+
+// EapAkaPrimeKeys is an EAP-AKA' challenge with the keys derived from it, hex encoded
+type EapAkaPrimeKeys struct {
+	Supi        string `json:"supi"`
+	NetworkName string `json:"networkName"`
+	Identity    string `json:"identity"`
+	Sqn         string `json:"sqn"`
+	Rand        string `json:"rand"`
+	Autn        string `json:"autn"`
+	Xres        string `json:"xres"`
+	CkPrime     string `json:"ckPrime"`
+	IkPrime     string `json:"ikPrime"`
+	Mk          string `json:"mk"`
+	KEncr       string `json:"kEncr"`
+	KAut        string `json:"kAut"`
+	KRe         string `json:"kRe"`
+	Msk         string `json:"msk"`
+	Emsk        string `json:"emsk"`
+}
+
+// ResyncRequest carries the AUTS a UE returned for a RAND whose SQN it rejected, hex encoded
+type ResyncRequest struct {
+	Rand string `json:"rand"`
+	Auts string `json:"auts"`
+}
+
+// SqnState is the sequence number SQN_HE = SEQ || IND of the last authentication vector of a UE;
+// the next vector uses the following SEQ and IND
+type SqnState struct {
+	Supi string `json:"supi"`
+	Sqn  string `json:"sqn"`
+	Seq  uint64 `json:"seq"`
+	Ind  int    `json:"ind"`
+}
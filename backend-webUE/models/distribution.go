@@ -0,0 +1,34 @@
+package models
+
+// BatchDistribution declares how a generated batch is shared out, in percent. Every list that is
+// set must add up to 100; a list left empty keeps the random or fixed choice of the operator.
+type BatchDistribution struct {
+	Schemes      []SchemeShare      `bson:"schemes,omitempty" json:"schemes,omitempty"`
+	OpTypes      []OpTypeShare      `bson:"optypes,omitempty" json:"opTypes,omitempty"`
+	Slices       []SliceShare       `bson:"slices,omitempty" json:"slices,omitempty"`
+	SessionTypes []SessionTypeShare `bson:"sessiontypes,omitempty" json:"sessionTypes,omitempty"`
+}
+
+// SchemeShare is the share of UEs with a SUCI protection scheme
+type SchemeShare struct {
+	Scheme  int `bson:"scheme" json:"scheme"`
+	Percent int `bson:"percent" json:"percent"`
+}
+
+// OpTypeShare is the share of UEs storing OP or OPc (TOP or TOPc for TUAK)
+type OpTypeShare struct {
+	OpType  string `bson:"optype" json:"opType"`
+	Percent int    `bson:"percent" json:"percent"`
+}
+
+// SliceShare is the share of UEs whose default S-NSSAI, and the slice of their sessions, is Slice
+type SliceShare struct {
+	Slice   Snssai `bson:"slice" json:"slice"`
+	Percent int    `bson:"percent" json:"percent"`
+}
+
+// SessionTypeShare is the share of UEs whose PDU sessions have the session type
+type SessionTypeShare struct {
+	Type    string `bson:"type" json:"type"`
+	Percent int    `bson:"percent" json:"percent"`
+}
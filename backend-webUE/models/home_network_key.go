@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Home network key states
+const (
+	HomeNetworkKeyActive  = "active"
+	HomeNetworkKeyRetired = "retired"
+)
+
+// HomeNetworkKey is a SUCI protection key pair registered under a home network public key ID
+type HomeNetworkKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	KeyId      int                `bson:"keyId" json:"keyId"`
+	Scheme     int                `bson:"scheme" json:"scheme"`
+	PublicKey  string             `bson:"publicKey" json:"publicKey"`
+	PrivateKey string             `bson:"privateKey" json:"-"` // only used server-side for de-concealment
+	Status     string             `bson:"status" json:"status"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	RetiredAt  time.Time          `bson:"retiredAt,omitempty" json:"retiredAt,omitempty"`
+}
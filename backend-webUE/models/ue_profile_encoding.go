@@ -0,0 +1,108 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
+)
+
+// ueProfileFields is a UE Profile without its encoding methods, encoded with its field tags
+type ueProfileFields UeProfile
+
+// privateKeyField is the name of the UE Profile field that documents stored before the home
+// network key registry carried the home network private key in. A UE never needs that key, so
+// UE Profiles are encoded without it in API responses, the database and YAML exports.
+const privateKeyField = "HomeNetworkPrivateKey"
+
+// privateKeyName returns the name of the home network private key under a format's field tags
+func privateKeyName(format string) string {
+	field, _ := reflect.TypeOf(UeProfile{}).FieldByName(privateKeyField)
+	if name, _, _ := strings.Cut(field.Tag.Get(format), ","); name != "" {
+		return name
+	}
+	if format == "json" {
+		return field.Name
+	}
+	return strings.ToLower(field.Name)
+}
+
+// MarshalJSON encodes a UE Profile without its home network private key
+func (ue UeProfile) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(ueProfileFields(ue))
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the members in order, skipping the private key
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	out.WriteByte('{')
+	skip := privateKeyName("json")
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		if token == skip {
+			continue
+		}
+		if out.Len() > 1 {
+			out.WriteByte(',')
+		}
+		key, _ := json.Marshal(token)
+		out.Write(key)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// MarshalBSON encodes a UE Profile without its home network private key, so no insert or $set
+// of a UE Profile writes the field back
+func (ue UeProfile) MarshalBSON() ([]byte, error) {
+	data, err := bson.Marshal(ueProfileFields(ue))
+	if err != nil {
+		return nil, err
+	}
+	elements, err := bson.Raw(data).Elements()
+	if err != nil {
+		return nil, err
+	}
+	skip := privateKeyName("bson")
+	doc := make(bson.D, 0, len(elements))
+	for _, element := range elements {
+		if element.Key() != skip {
+			doc = append(doc, bson.E{Key: element.Key(), Value: element.Value()})
+		}
+	}
+	return bson.Marshal(doc)
+}
+
+// MarshalYAML encodes a UE Profile without its home network private key, whether it is exported
+// alone or in a list
+func (ue UeProfile) MarshalYAML() (interface{}, error) {
+	var node yaml.Node
+	if err := node.Encode(ueProfileFields(ue)); err != nil {
+		return nil, err
+	}
+	skip := privateKeyName("yaml")
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == skip {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			break
+		}
+	}
+	return &node, nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
+)
+
+const testPrivateKey = "F1AB1074477EBCC7F554EA1C5FC368B1616730155E0041AC447D6301975FECDA"
+
+func TestUeProfileEncodingsLeaveOutPrivateKey(t *testing.T) {
+	ue := UeProfile{
+		Supi:                  "imsi-208930000000001",
+		HomeNetworkPrivateKey: testPrivateKey,
+		HomeNetworkPublicKey:  "0272DA71976234CE833A6907425867B82E074D44EF907DFB4B3E21C1C2256EBCD1",
+	}
+
+	data, err := json.Marshal([]UeProfile{ue})
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("MarshalJSON wrote invalid JSON %s: %v", data, err)
+	}
+	if strings.Contains(string(data), testPrivateKey) || decoded[0]["supi"] != ue.Supi {
+		t.Fatalf("JSON = %s", data)
+	}
+
+	doc, err := bson.Marshal(bson.M{"$set": &ue})
+	if err != nil {
+		t.Fatalf("bson.Marshal failed: %v", err)
+	}
+	set := bson.Raw(doc).Lookup("$set").Document()
+	if _, err := set.LookupErr(privateKeyName("bson")); err == nil {
+		t.Fatalf("BSON $set writes the private key: %v", set)
+	}
+	if supi, _ := set.Lookup("supi").StringValueOK(); supi != ue.Supi {
+		t.Fatalf("BSON $set = %v", set)
+	}
+
+	data, err = yaml.Marshal([]UeProfile{ue})
+	if err != nil {
+		t.Fatalf("yaml.Marshal failed: %v", err)
+	}
+	if strings.Contains(string(data), testPrivateKey) || !strings.Contains(string(data), "supi: imsi-208930000000001") {
+		t.Fatalf("YAML = %s", data)
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UeTemplate is a named fleet definition that UE Profiles are generated from. ProtectionSchemes
+// is its key profile: each generated UE gets one of the schemes, keyed with the scheme's active
+// home network key.
+type UeTemplate struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name              string             `bson:"name" json:"name"`
+	Description       string             `bson:"description" json:"description"`
+	PlmnId            PlmnId             `bson:"plmnid" json:"plmnid"`
+	UeConfiguredNssai []Snssai           `bson:"configuredslice" json:"ueConfiguredNssai"`
+	UeDefaultNssai    []Snssai           `bson:"defaultslice" json:"ueDefaultNssai"`
+	Sessions          []Sessions         `bson:"sessions" json:"sessions"`
+	Integrity         Integrity          `bson:"integrity" json:"integrity"`
+	Ciphering         Ciphering          `bson:"ciphering" json:"ciphering"`
+	IntegrityMaxRate  IntegrityMaxRate   `bson:"integritymaxrate" json:"integrityMaxRate"`
+	UacAic            UacAic             `bson:"uacaic" json:"uacAic"`
+	UacAcc            UacAcc             `bson:"uacacc" json:"uacAcc"`
+	ProtectionSchemes []int              `bson:"protectionschemes" json:"protectionSchemes"`
+	AuthAlgorithm     string             `bson:"authalgorithm" json:"authAlgorithm"`
+	Amf               string             `bson:"amf" json:"amf"`
+	GnbSearchList     []string           `bson:"gnbsearchlist" json:"gnbSearchList"`
+	Tacs              []string           `bson:"tacs" json:"tacs"`
+	Svn               string             `bson:"svn" json:"svn"`
+	Distribution      *BatchDistribution `bson:"distribution,omitempty" json:"distribution,omitempty"`
+	CreatedAt         time.Time          `bson:"createdat" json:"createdAt"`
+	UpdatedAt         time.Time          `bson:"updatedat" json:"updatedAt"`
+}
+
+// UeTemplateOverrides replaces fields of a template for a single generation; fields left
+// out keep the template's value
+type UeTemplateOverrides struct {
+	PlmnId            *PlmnId            `json:"plmnid,omitempty"`
+	UeConfiguredNssai []Snssai           `json:"ueConfiguredNssai,omitempty"`
+	UeDefaultNssai    []Snssai           `json:"ueDefaultNssai,omitempty"`
+	Sessions          []Sessions         `json:"sessions,omitempty"`
+	Integrity         *Integrity         `json:"integrity,omitempty"`
+	Ciphering         *Ciphering         `json:"ciphering,omitempty"`
+	IntegrityMaxRate  *IntegrityMaxRate  `json:"integrityMaxRate,omitempty"`
+	UacAic            *UacAic            `json:"uacAic,omitempty"`
+	UacAcc            *UacAcc            `json:"uacAcc,omitempty"`
+	ProtectionSchemes []int              `json:"protectionSchemes,omitempty"`
+	AuthAlgorithm     *string            `json:"authAlgorithm,omitempty"`
+	Amf               *string            `json:"amf,omitempty"`
+	GnbSearchList     []string           `json:"gnbSearchList,omitempty"`
+	Tacs              []string           `json:"tacs,omitempty"`
+	Svn               *string            `json:"svn,omitempty"`
+	Distribution      *BatchDistribution `json:"distribution,omitempty"`
+}
+
+// TemplateGenerateRequest is a POST /ue_profiles/generate request that generates UE Profiles from a
+// stored template. The seed and the SUPI allocation belong to the batch rather than to the fleet definition.
+type TemplateGenerateRequest struct {
+	TemplateId     string              `json:"templateId"`
+	NumUes         int                 `json:"num_ues"`
+	Seed           *int64              `json:"seed,omitempty"`
+	SupiAllocation string              `json:"supiAllocation,omitempty"`
+	MsinStart      string              `json:"msinStart,omitempty"`
+	MsinEnd        string              `json:"msinEnd,omitempty"`
+	Overrides      UeTemplateOverrides `json:"overrides"`
+}
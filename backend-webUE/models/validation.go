@@ -0,0 +1,14 @@
+package models
+
+// FieldError is a validation error of one field of a UE Profile. Field is the JSON path of the
+// field, e.g. plmnid.mnc or sessions[1].slice.sd.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationReport is the result of validating a UE Profile without writing it
+type ValidationReport struct {
+	Valid  bool         `json:"valid"`
+	Errors []FieldError `json:"errors"`
+}
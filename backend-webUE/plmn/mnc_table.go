@@ -0,0 +1,43 @@
+// plmn/mnc_table.go
+package plmn
+
+// threeDigitMncs lists the MCCs whose networks are assigned three-digit MNCs (ITU-T E.212 list
+// of mobile network codes). Every other MCC uses two-digit MNCs.
+var threeDigitMncs = map[string]bool{
+	"302": true, // Canada
+	"310": true, // United States
+	"311": true, // United States
+	"312": true, // United States
+	"313": true, // United States
+	"314": true, // United States
+	"315": true, // United States
+	"316": true, // United States
+	"334": true, // Mexico
+	"338": true, // Jamaica
+	"342": true, // Barbados
+	"344": true, // Antigua and Barbuda
+	"346": true, // Cayman Islands
+	"348": true, // British Virgin Islands
+	"350": true, // Bermuda
+	"352": true, // Grenada
+	"354": true, // Montserrat
+	"356": true, // Saint Kitts and Nevis
+	"358": true, // Saint Lucia
+	"360": true, // Saint Vincent and the Grenadines
+	"365": true, // Anguilla
+	"366": true, // Dominica
+	"376": true, // Turks and Caicos Islands
+	"405": true, // India
+	"708": true, // Honduras
+	"722": true, // Argentina
+	"732": true, // Colombia
+	"750": true, // Falkland Islands
+}
+
+// MncLength returns the MNC length of an MCC's networks
+func MncLength(mcc string) int {
+	if threeDigitMncs[mcc] {
+		return 3
+	}
+	return 2
+}
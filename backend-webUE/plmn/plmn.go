@@ -0,0 +1,120 @@
+// plmn/plmn.go
+//
+// Package plmn models a PLMN identity whose MNC length is part of its value, so that
+// two-digit MNCs (208-93) and three-digit MNCs (310-410) stay distinct through IMSIs,
+// SUCIs, realms and exports, and splits bare IMSI digits with a table of MNC lengths.
+package plmn
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	MccLen     = 3  // digits
+	ImsiMaxLen = 15 // digits
+)
+
+// Plmn is a PLMN identity. Mnc keeps its length: "093" and "93" are different PLMNs.
+type Plmn struct {
+	Mcc string
+	Mnc string
+}
+
+// New validates an MCC of 3 digits and an MNC of 2 or 3 digits
+func New(mcc, mnc string) (Plmn, error) {
+	if len(mcc) != MccLen || !isDigits(mcc) {
+		return Plmn{}, fmt.Errorf("invalid MCC %q: must have 3 digits", mcc)
+	}
+	if (len(mnc) != 2 && len(mnc) != 3) || !isDigits(mnc) {
+		return Plmn{}, fmt.Errorf("invalid MNC %q: must have 2 or 3 digits", mnc)
+	}
+	return Plmn{Mcc: mcc, Mnc: mnc}, nil
+}
+
+// Parse parses a PLMN written <mcc>-<mnc>, e.g. 310-410
+func Parse(s string) (Plmn, error) {
+	mcc, mnc, ok := strings.Cut(s, "-")
+	if !ok {
+		return Plmn{}, fmt.Errorf("invalid PLMN %q: expected <mcc>-<mnc>", s)
+	}
+	return New(mcc, mnc)
+}
+
+// String returns the PLMN as <mcc>-<mnc>
+func (p Plmn) String() string {
+	return p.Mcc + "-" + p.Mnc
+}
+
+// MncLen returns the number of digits of the MNC
+func (p Plmn) MncLen() int {
+	return len(p.Mnc)
+}
+
+// Digits returns MCC || MNC, the leading digits of the PLMN's IMSIs
+func (p Plmn) Digits() string {
+	return p.Mcc + p.Mnc
+}
+
+// MsinLen returns the number of MSIN digits of a 15-digit IMSI of the PLMN
+func (p Plmn) MsinLen() int {
+	return ImsiMaxLen - MccLen - p.MncLen()
+}
+
+// Mnc3 returns the MNC padded to 3 digits, as domain names carry it (TS 23.003 clause 28.2)
+func (p Plmn) Mnc3() string {
+	if len(p.Mnc) == 2 {
+		return "0" + p.Mnc
+	}
+	return p.Mnc
+}
+
+// NaiRealm returns the realm of the PLMN's NAI-type SUPIs, nai.5gc.mnc<MNC>.mcc<MCC>.3gppnetwork.org
+func (p Plmn) NaiRealm() string {
+	return "nai.5gc.mnc" + p.Mnc3() + ".mcc" + p.Mcc + ".3gppnetwork.org"
+}
+
+// ServingNetworkName returns the 5G serving network name, 5G:mnc<MNC>.mcc<MCC>.3gppnetwork.org (TS 24.501 clause 9.12.1)
+func (p Plmn) ServingNetworkName() string {
+	return "5G:mnc" + p.Mnc3() + ".mcc" + p.Mcc + ".3gppnetwork.org"
+}
+
+// SplitImsi returns the MSIN of the digits of an IMSI of the PLMN
+func (p Plmn) SplitImsi(imsi string) (string, error) {
+	if !isDigits(imsi) || len(imsi) > ImsiMaxLen || len(imsi) <= len(p.Digits()) {
+		return "", fmt.Errorf("invalid IMSI %q", imsi)
+	}
+	if !strings.HasPrefix(imsi, p.Digits()) {
+		return "", fmt.Errorf("IMSI %s does not belong to PLMN %s", imsi, p)
+	}
+	return imsi[len(p.Digits()):], nil
+}
+
+// ParseImsi splits the digits of an IMSI into its PLMN and MSIN. The MNC length is taken from
+// the known PLMNs first, such as the configured operators, and then from the MNC length table.
+func ParseImsi(imsi string, known ...Plmn) (Plmn, string, error) {
+	if !isDigits(imsi) || len(imsi) < MccLen+2+1 || len(imsi) > ImsiMaxLen {
+		return Plmn{}, "", fmt.Errorf("invalid IMSI %q", imsi)
+	}
+	for _, p := range known {
+		if strings.HasPrefix(imsi, p.Digits()) && len(imsi) > len(p.Digits()) {
+			return p, imsi[len(p.Digits()):], nil
+		}
+	}
+	mcc := imsi[:MccLen]
+	mncLen := MncLength(mcc)
+	p := Plmn{Mcc: mcc, Mnc: imsi[MccLen : MccLen+mncLen]}
+	if len(imsi) <= len(p.Digits()) {
+		return Plmn{}, "", fmt.Errorf("invalid IMSI %q", imsi)
+	}
+	return p, imsi[len(p.Digits()):], nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
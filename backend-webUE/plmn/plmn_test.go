@@ -0,0 +1,79 @@
+package plmn
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	for _, c := range []struct {
+		mcc, mnc string
+		valid    bool
+	}{
+		{"208", "93", true},
+		{"310", "410", true},
+		{"001", "001", true},
+		{"20", "93", false},
+		{"208", "9", false},
+		{"208", "9301", false},
+		{"2a8", "93", false},
+	} {
+		if _, err := New(c.mcc, c.mnc); (err == nil) != c.valid {
+			t.Errorf("New(%q, %q) = %v, want valid %v", c.mcc, c.mnc, err, c.valid)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	two, _ := Parse("208-93")
+	three, _ := Parse("310-410")
+	if two.MsinLen() != 10 || three.MsinLen() != 9 {
+		t.Fatalf("MsinLen = %d, %d, want 10, 9", two.MsinLen(), three.MsinLen())
+	}
+	if got := two.ServingNetworkName(); got != "5G:mnc093.mcc208.3gppnetwork.org" {
+		t.Fatalf("ServingNetworkName = %s", got)
+	}
+	if got := three.NaiRealm(); got != "nai.5gc.mnc410.mcc310.3gppnetwork.org" {
+		t.Fatalf("NaiRealm = %s", got)
+	}
+	if three.String() != "310-410" {
+		t.Fatalf("String = %s", three)
+	}
+}
+
+func TestParseImsi(t *testing.T) {
+	for _, c := range []struct {
+		imsi, plmn, msin string
+	}{
+		{"208930000000001", "208-93", "0000000001"},
+		{"310410123456789", "310-410", "123456789"},
+		{"302720123456789", "302-720", "123456789"},
+	} {
+		p, msin, err := ParseImsi(c.imsi)
+		if err != nil {
+			t.Fatalf("ParseImsi(%s) failed: %v", c.imsi, err)
+		}
+		if p.String() != c.plmn || msin != c.msin {
+			t.Errorf("ParseImsi(%s) = %s, %s, want %s, %s", c.imsi, p, msin, c.plmn, c.msin)
+		}
+	}
+
+	// A known PLMN overrides the table: 208 uses two-digit MNCs
+	known, _ := New("208", "093")
+	if p, msin, _ := ParseImsi("208093000000001", known); p != known || msin != "000000001" {
+		t.Fatalf("ParseImsi with a known PLMN = %s, %s", p, msin)
+	}
+
+	for _, imsi := range []string{"", "20893", "2089300000000011", "20893000000000a"} {
+		if _, _, err := ParseImsi(imsi); err == nil {
+			t.Errorf("ParseImsi(%q) accepted an invalid IMSI", imsi)
+		}
+	}
+}
+
+func TestSplitImsi(t *testing.T) {
+	p, _ := New("310", "410")
+	if msin, err := p.SplitImsi("310410123456789"); err != nil || msin != "123456789" {
+		t.Fatalf("SplitImsi = %s, %v", msin, err)
+	}
+	if _, err := p.SplitImsi("310041123456789"); err == nil {
+		t.Fatal("SplitImsi accepted an IMSI of another PLMN")
+	}
+}
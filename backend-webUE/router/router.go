@@ -11,7 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(ueProfileAPI *api.UeProfileAPI, userAPI *api.UserAPI, userService *services.UserService, serverConfig config.ServerConfig, jwtSecret string) *gin.Engine {
+func SetupRouter(ueProfileAPI *api.UeProfileAPI, authVectorAPI *api.AuthVectorAPI, ueTemplateAPI *api.UeTemplateAPI, validationAPI *api.UeProfileValidationAPI, userAPI *api.UserAPI, userService *services.UserService, serverConfig config.ServerConfig, jwtSecret string) *gin.Engine {
 
 	// Initialize router
 	router := gin.Default()
@@ -32,8 +32,12 @@ func SetupRouter(ueProfileAPI *api.UeProfileAPI, userAPI *api.UserAPI, userServi
 	//Protected routes
 	protected := router.Group("/")
 	protected.Use(middleware.AuthMiddleware(userService, jwtSecret))
+	protected.Use(ueTemplateAPI.TemplateGeneration())
 
 	ueProfileAPI.RegisterRoutes(protected)
+	authVectorAPI.RegisterRoutes(protected)
+	ueTemplateAPI.RegisterRoutes(protected)
+	validationAPI.RegisterRoutes(protected)
 
 	return router
 }
@@ -0,0 +1,320 @@
+// secrets/keyring.go
+//
+// Package secrets implements envelope encryption of subscriber secrets at rest. Every value
+// is encrypted with its own random data key (AES-256-GCM) and the data key is wrapped with a
+// versioned master key read from a local master key file. Encrypted values are strings of
+// the form enc:v<version>:<wrapped data key>:<ciphertext>, so they fit the existing fields,
+// and each ciphertext is bound to the owner and field of its value.
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	KeyLen = 32 // octets, master keys and data keys (AES-256)
+
+	// MasterKeyFileEnv names the environment variable holding the path of the master key file
+	MasterKeyFileEnv = "MASTER_KEY_FILE"
+
+	encryptedPrefix = "enc:"
+)
+
+// Keyring holds the master keys by version. New values are encrypted with the highest version;
+// values encrypted with any version in the keyring can be decrypted.
+type Keyring struct {
+	keys   map[int][]byte
+	active int
+}
+
+// NewKeyring creates a keyring from master keys by version
+func NewKeyring(keys map[int][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring has no master key")
+	}
+	k := &Keyring{keys: make(map[int][]byte)}
+	for version, key := range keys {
+		if version < 1 {
+			return nil, fmt.Errorf("invalid master key version: %d", version)
+		}
+		if len(key) != KeyLen {
+			return nil, fmt.Errorf("master key version %d must be %d octets, got %d", version, KeyLen, len(key))
+		}
+		k.keys[version] = append([]byte(nil), key...)
+		if version > k.active {
+			k.active = version
+		}
+	}
+	return k, nil
+}
+
+// LoadKeyring reads a master key file. Each line holds a version and a hex master key;
+// empty lines and lines starting with # are ignored.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master key file: %v", err)
+	}
+	keys, err := parseMasterKeys(data)
+	if err != nil {
+		return nil, fmt.Errorf("master key file %s: %v", path, err)
+	}
+	return NewKeyring(keys)
+}
+
+// LoadKeyringFromEnv reads the master key file named by MASTER_KEY_FILE. It returns a nil
+// keyring, which stores secrets in clear, when the variable is not set.
+func LoadKeyringFromEnv() (*Keyring, error) {
+	path := os.Getenv(MasterKeyFileEnv)
+	if path == "" {
+		return nil, nil
+	}
+	return LoadKeyring(path)
+}
+
+// AddMasterKey appends a new random master key to the master key file, creating the file if
+// needed, and returns its version. The new key becomes the active one.
+func AddMasterKey(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read master key file: %v", err)
+	}
+	keys, err := parseMasterKeys(data)
+	if err != nil {
+		return 0, fmt.Errorf("master key file %s: %v", path, err)
+	}
+
+	version := 1
+	for v := range keys {
+		if v >= version {
+			version = v + 1
+		}
+	}
+	key := make([]byte, KeyLen)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return 0, fmt.Errorf("failed to generate master key: %v", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open master key file: %v", err)
+	}
+	defer f.Close()
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		if _, err := f.WriteString("\n"); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := fmt.Fprintf(f, "%d %s\n", version, hex.EncodeToString(key)); err != nil {
+		return 0, fmt.Errorf("failed to write master key file: %v", err)
+	}
+	return version, nil
+}
+
+func parseMasterKeys(data []byte) (map[int][]byte, error) {
+	keys := make(map[int][]byte)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected <version> <hex key>", line)
+		}
+		version, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid version %q", line, fields[0])
+		}
+		if _, ok := keys[version]; ok {
+			return nil, fmt.Errorf("line %d: duplicate version %d", line, version)
+		}
+		key, err := hex.DecodeString(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid key: %v", line, err)
+		}
+		keys[version] = key
+	}
+	return keys, scanner.Err()
+}
+
+// ActiveVersion returns the version of the master key new values are encrypted with,
+// or 0 for a nil keyring
+func (k *Keyring) ActiveVersion() int {
+	if k == nil {
+		return 0
+	}
+	return k.active
+}
+
+// Encrypt seals a value with a fresh data key wrapped by the active master key. The ciphertext is
+// bound to the value's owner and field, e.g. a SUPI and "key", so it cannot be moved to another
+// record or field. A nil keyring and empty values are returned unchanged.
+func (k *Keyring) Encrypt(plaintext, owner, field string) (string, error) {
+	if k == nil || plaintext == "" {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, KeyLen)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %v", err)
+	}
+	header := fmt.Sprintf("%sv%d", encryptedPrefix, k.active)
+
+	// The header is authenticated along with the wrapped data key so its version tag cannot be swapped
+	wrappedKey, err := seal(k.keys[k.active], dataKey, []byte(header))
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, []byte(plaintext), associatedData(owner, field))
+	if err != nil {
+		return "", err
+	}
+	return header + ":" + base64.RawStdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens an encrypted value of an owner and field with the master key of its version.
+// Without a keyring, values stored in clear are returned unchanged; with one, every non-empty
+// value must be encrypted, so a value written around the encryption is rejected.
+func (k *Keyring) Decrypt(value, owner, field string) (string, error) {
+	if !IsEncrypted(value) {
+		if k != nil && value != "" {
+			return "", fmt.Errorf("value is stored in clear but a master key is configured")
+		}
+		return value, nil
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	version, ok := KeyVersion(value)
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted value version %q", parts[1])
+	}
+	if k == nil {
+		return "", fmt.Errorf("value is encrypted with master key version %d but no master key is configured", version)
+	}
+	masterKey, ok := k.keys[version]
+	if !ok {
+		return "", fmt.Errorf("master key version %d is not in the keyring", version)
+	}
+
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed wrapped data key: %v", err)
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext: %v", err)
+	}
+	dataKey, err := open(masterKey, wrappedKey, []byte(parts[0]+":"+parts[1]))
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %v", err)
+	}
+	plaintext, err := open(dataKey, ciphertext, associatedData(owner, field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// Reencrypt decrypts a value and encrypts it again with the active master key, encrypting values
+// stored in clear. Values already encrypted with the active version are returned unchanged; the
+// boolean reports a change.
+func (k *Keyring) Reencrypt(value, owner, field string) (string, bool, error) {
+	if k == nil {
+		return "", false, fmt.Errorf("no master key is configured")
+	}
+	if value == "" {
+		return value, false, nil
+	}
+	if version, ok := KeyVersion(value); ok && version == k.active {
+		return value, false, nil
+	}
+	plaintext := value
+	if IsEncrypted(value) {
+		var err error
+		if plaintext, err = k.Decrypt(value, owner, field); err != nil {
+			return "", false, err
+		}
+	}
+	sealed, err := k.Encrypt(plaintext, owner, field)
+	if err != nil {
+		return "", false, err
+	}
+	return sealed, true, nil
+}
+
+// IsEncrypted reports whether a stored value is an encrypted envelope
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// KeyVersion returns the master key version an encrypted value is tagged with
+func KeyVersion(value string) (int, bool) {
+	if !IsEncrypted(value) {
+		return 0, false
+	}
+	tag, _, _ := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !strings.HasPrefix(tag, "v") {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag[1:])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// associatedData binds a ciphertext to the owner and field of its value. The owner is length
+// prefixed so that the boundary between owner and field cannot be shifted.
+func associatedData(owner, field string) []byte {
+	return []byte(fmt.Sprintf("%d:%s:%s", len(owner), owner, field))
+}
+
+// seal encrypts with AES-GCM under a random nonce and returns nonce || ciphertext
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts nonce || ciphertext produced by seal
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
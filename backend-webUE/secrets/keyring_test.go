@@ -0,0 +1,145 @@
+package secrets
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, versions ...int) *Keyring {
+	keys := make(map[int][]byte)
+	for _, v := range versions {
+		keys[v] = bytes.Repeat([]byte{byte(v)}, KeyLen)
+	}
+	k, err := NewKeyring(keys)
+	if err != nil {
+		t.Fatalf("NewKeyring failed: %v", err)
+	}
+	return k
+}
+
+const testSupi = "imsi-208930000000001"
+
+func TestEncryptDecrypt(t *testing.T) {
+	k := testKeyring(t, 1)
+	const secret = "465b5ce8b199b49faa5f0a2ee238a6bc"
+
+	sealed, err := k.Encrypt(secret, testSupi, "key")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !IsEncrypted(sealed) || strings.Contains(sealed, secret) {
+		t.Fatalf("value is not encrypted: %s", sealed)
+	}
+	if version, ok := KeyVersion(sealed); !ok || version != 1 {
+		t.Fatalf("KeyVersion = %d, %v, want 1", version, ok)
+	}
+	if again, _ := k.Encrypt(secret, testSupi, "key"); again == sealed {
+		t.Fatal("two encryptions of a value are equal")
+	}
+
+	opened, err := k.Decrypt(sealed, testSupi, "key")
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if opened != secret {
+		t.Fatalf("Decrypt = %s, want %s", opened, secret)
+	}
+
+	// The ciphertext only opens for the owner and field it was sealed for
+	if _, err := k.Decrypt(sealed, "imsi-208930000000002", "key"); err == nil {
+		t.Fatal("Decrypt accepted a value moved to another SUPI")
+	}
+	if _, err := k.Decrypt(sealed, testSupi, "op"); err == nil {
+		t.Fatal("Decrypt accepted a value moved to another field")
+	}
+
+	// Once a master key is configured, clear values are rejected; without one they read back unchanged
+	if _, err := k.Decrypt(secret, testSupi, "key"); err == nil {
+		t.Fatal("Decrypt accepted a clear value with a master key configured")
+	}
+	var none *Keyring
+	if plain, err := none.Decrypt(secret, testSupi, "key"); err != nil || plain != secret {
+		t.Fatalf("Decrypt of a clear value without a keyring = %s, %v", plain, err)
+	}
+	if empty, err := k.Decrypt("", testSupi, "key"); err != nil || empty != "" {
+		t.Fatalf("Decrypt of an empty value = %q, %v", empty, err)
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	k := testKeyring(t, 1, 2)
+	sealed, _ := k.Encrypt("secret", testSupi, "key")
+
+	tampered := []byte(sealed)
+	tampered[len(tampered)-2] ^= 0x01
+	if _, err := k.Decrypt(string(tampered), testSupi, "key"); err == nil {
+		t.Fatal("Decrypt accepted a tampered ciphertext")
+	}
+	if _, err := k.Decrypt(strings.Replace(sealed, "enc:v2:", "enc:v1:", 1), testSupi, "key"); err == nil {
+		t.Fatal("Decrypt accepted a swapped key version")
+	}
+	if _, err := testKeyring(t, 1).Decrypt(sealed, testSupi, "key"); err == nil {
+		t.Fatal("Decrypt succeeded without the master key version")
+	}
+	var none *Keyring
+	if _, err := none.Decrypt(sealed, testSupi, "key"); err == nil {
+		t.Fatal("Decrypt succeeded without a keyring")
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	old := testKeyring(t, 1)
+	sealed, _ := old.Encrypt("secret", testSupi, "op")
+
+	rotated := testKeyring(t, 1, 2)
+	reencrypted, changed, err := rotated.Reencrypt(sealed, testSupi, "op")
+	if err != nil || !changed {
+		t.Fatalf("Reencrypt = %v, %v", changed, err)
+	}
+	if version, _ := KeyVersion(reencrypted); version != 2 {
+		t.Fatalf("re-encrypted with version %d, want 2", version)
+	}
+	if opened, _ := testKeyring(t, 2).Decrypt(reencrypted, testSupi, "op"); opened != "secret" {
+		t.Fatalf("Decrypt = %s, want secret", opened)
+	}
+	if _, changed, _ := rotated.Reencrypt(reencrypted, testSupi, "op"); changed {
+		t.Fatal("Reencrypt changed a value already at the active version")
+	}
+
+	// Clear values are encrypted
+	sealed, changed, err = rotated.Reencrypt("secret", testSupi, "op")
+	if err != nil || !changed || !IsEncrypted(sealed) {
+		t.Fatalf("Reencrypt of a clear value = %s, %v, %v", sealed, changed, err)
+	}
+	if opened, _ := rotated.Decrypt(sealed, testSupi, "op"); opened != "secret" {
+		t.Fatalf("Decrypt = %s, want secret", opened)
+	}
+}
+
+func TestMasterKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master.key")
+	for want := 1; want <= 2; want++ {
+		version, err := AddMasterKey(path)
+		if err != nil {
+			t.Fatalf("AddMasterKey failed: %v", err)
+		}
+		if version != want {
+			t.Fatalf("AddMasterKey = %d, want %d", version, want)
+		}
+	}
+
+	k, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatalf("LoadKeyring failed: %v", err)
+	}
+	if k.ActiveVersion() != 2 {
+		t.Fatalf("ActiveVersion = %d, want 2", k.ActiveVersion())
+	}
+
+	t.Setenv(MasterKeyFileEnv, "")
+	if k, err := LoadKeyringFromEnv(); k != nil || err != nil {
+		t.Fatalf("LoadKeyringFromEnv without %s = %v, %v", MasterKeyFileEnv, k, err)
+	}
+}
@@ -0,0 +1,197 @@
+// services/auth_vector.go
+package services
+
+import (
+	"backend-webUE/aka"
+	"backend-webUE/milenage"
+	"backend-webUE/models"
+	"backend-webUE/tuak"
+	"backend-webUE/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// GenerateAuthVector computes a 5G-AKA authentication vector for a stored UE Profile.
+// The serving network name defaults to the UE's home PLMN. Without an explicit SQN the
+// vector takes the next SQN of the UE's sequence number state, once the request is validated.
+func (s *UeProfileService) GenerateAuthVector(supi string, req models.AuthVectorRequest) (*models.AuthVector, error) {
+	ue, err := s.GetUeProfile(supi)
+	if err != nil {
+		return nil, err
+	}
+	return profileAuthVector(ue, req, s.requestSqn(supi, req.Sqn))
+}
+
+// ProfileAuthVector computes a 5G-AKA authentication vector from the K, OP/OPc and AMF of a UE Profile
+func ProfileAuthVector(ue *models.UeProfile, req models.AuthVectorRequest) (*models.AuthVector, error) {
+	return profileAuthVector(ue, req, func() ([]byte, error) { return decodeSqn(req.Sqn) })
+}
+
+func profileAuthVector(ue *models.UeProfile, req models.AuthVectorRequest, sqnSource func() ([]byte, error)) (*models.AuthVector, error) {
+	servingNetworkName := req.ServingNetworkName
+	if servingNetworkName == "" {
+		p, err := utils.PlmnOf(ue.PlmnId)
+		if err != nil {
+			return nil, err
+		}
+		servingNetworkName = p.ServingNetworkName()
+	}
+	if err := aka.ValidateServingNetworkName(servingNetworkName); err != nil {
+		return nil, invalidRequest(err)
+	}
+
+	alg, sqn, amf, randBytes, err := authInputs(ue, req.Rand, sqnSource)
+	if err != nil {
+		return nil, err
+	}
+
+	av, err := aka.Generate5G(alg, randBytes, sqn, amf, servingNetworkName)
+	if err != nil {
+		log.Printf("Error generating authentication vector for SUPI %s: %v", ue.Supi, err)
+		return nil, err
+	}
+	return &models.AuthVector{
+		Supi:               ue.Supi,
+		ServingNetworkName: servingNetworkName,
+		Sqn:                hex.EncodeToString(sqn),
+		Rand:               hex.EncodeToString(av.Rand),
+		Autn:               hex.EncodeToString(av.Autn),
+		XresStar:           hex.EncodeToString(av.XresStar),
+		HxresStar:          hex.EncodeToString(av.HxresStar),
+		Kausf:              hex.EncodeToString(av.Kausf),
+		Kseaf:              hex.EncodeToString(av.Kseaf),
+	}, nil
+}
+
+// GenerateEapAkaPrimeKeys runs the authentication functions of a stored UE Profile and derives
+// the EAP-AKA' keys for the given access network name. Without an explicit SQN the challenge
+// takes the next SQN of the UE's sequence number state, once the request is validated.
+func (s *UeProfileService) GenerateEapAkaPrimeKeys(supi string, req models.EapAkaPrimeRequest) (*models.EapAkaPrimeKeys, error) {
+	ue, err := s.GetUeProfile(supi)
+	if err != nil {
+		return nil, err
+	}
+	return profileEapAkaPrimeKeys(ue, req, s.requestSqn(supi, req.Sqn))
+}
+
+// ProfileEapAkaPrimeKeys derives the EAP-AKA' keys from the K, OP/OPc and AMF of a UE Profile.
+// The identity defaults to the SUPI without its type prefix.
+func ProfileEapAkaPrimeKeys(ue *models.UeProfile, req models.EapAkaPrimeRequest) (*models.EapAkaPrimeKeys, error) {
+	return profileEapAkaPrimeKeys(ue, req, func() ([]byte, error) { return decodeSqn(req.Sqn) })
+}
+
+func profileEapAkaPrimeKeys(ue *models.UeProfile, req models.EapAkaPrimeRequest, sqnSource func() ([]byte, error)) (*models.EapAkaPrimeKeys, error) {
+	if req.NetworkName == "" {
+		return nil, invalidRequest(fmt.Errorf("network name must not be empty"))
+	}
+	identity := req.Identity
+	if identity == "" {
+		identity = ue.Supi[strings.Index(ue.Supi, "-")+1:]
+	}
+
+	alg, sqn, amf, randBytes, err := authInputs(ue, req.Rand, sqnSource)
+	if err != nil {
+		return nil, err
+	}
+
+	autn, xres, keys, err := aka.GenerateEapAkaPrime(alg, randBytes, sqn, amf, req.NetworkName, identity)
+	if err != nil {
+		log.Printf("Error deriving EAP-AKA' keys for SUPI %s: %v", ue.Supi, err)
+		return nil, err
+	}
+	return &models.EapAkaPrimeKeys{
+		Supi:        ue.Supi,
+		NetworkName: req.NetworkName,
+		Identity:    identity,
+		Sqn:         hex.EncodeToString(sqn),
+		Rand:        hex.EncodeToString(randBytes),
+		Autn:        hex.EncodeToString(autn),
+		Xres:        hex.EncodeToString(xres),
+		CkPrime:     hex.EncodeToString(keys.CkPrime),
+		IkPrime:     hex.EncodeToString(keys.IkPrime),
+		Mk:          hex.EncodeToString(keys.Mk),
+		KEncr:       hex.EncodeToString(keys.KEncr),
+		KAut:        hex.EncodeToString(keys.KAut),
+		KRe:         hex.EncodeToString(keys.KRe),
+		Msk:         hex.EncodeToString(keys.Msk),
+		Emsk:        hex.EncodeToString(keys.Emsk),
+	}, nil
+}
+
+// requestSqn returns the SQN source of a request for a stored UE Profile: the request's SQN,
+// or else the next SQN of the UE's sequence number state
+func (s *UeProfileService) requestSqn(supi string, sqnHex string) func() ([]byte, error) {
+	if sqnHex != "" {
+		return func() ([]byte, error) { return decodeSqn(sqnHex) }
+	}
+	return func() ([]byte, error) { return s.nextSqn(supi) }
+}
+
+// decodeSqn decodes the hex SQN of an authentication request
+func decodeSqn(sqnHex string) ([]byte, error) {
+	sqn, err := hex.DecodeString(sqnHex)
+	if err != nil {
+		return nil, invalidRequest(fmt.Errorf("invalid SQN: %v", err))
+	}
+	if len(sqn) != aka.SqnLen {
+		return nil, invalidRequest(fmt.Errorf("invalid SQN: must be %d octets, got %d", aka.SqnLen, len(sqn)))
+	}
+	return sqn, nil
+}
+
+// authInputs sets up the authentication functions and AMF of a UE Profile and decodes the RAND of an
+// authentication request, drawing it at random when empty. The SQN is taken from sqnSource last,
+// so a request rejected for its other inputs does not advance the UE's sequence number state.
+func authInputs(ue *models.UeProfile, randHex string, sqnSource func() ([]byte, error)) (alg aka.Algorithm, sqn, amf, randBytes []byte, err error) {
+	if alg, err = profileAlgorithm(ue); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	amfHex := ue.Amf
+	if amfHex == "" {
+		amfHex = utils.DEFAULT_AMF
+	}
+	if amf, err = hex.DecodeString(amfHex); err != nil || len(amf) != aka.AmfLen {
+		return nil, nil, nil, nil, fmt.Errorf("invalid AMF %q of SUPI %s: must be %d octets hex encoded", amfHex, ue.Supi, aka.AmfLen)
+	}
+
+	if randHex == "" {
+		randBytes = make([]byte, aka.RandLen)
+		if _, err := rand.Read(randBytes); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	} else if randBytes, err = hex.DecodeString(randHex); err != nil {
+		return nil, nil, nil, nil, invalidRequest(fmt.Errorf("invalid RAND: %v", err))
+	} else if len(randBytes) != aka.RandLen {
+		return nil, nil, nil, nil, invalidRequest(fmt.Errorf("invalid RAND: must be %d octets, got %d", aka.RandLen, len(randBytes)))
+	}
+
+	if sqn, err = sqnSource(); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return alg, sqn, amf, randBytes, nil
+}
+
+// profileAlgorithm sets up the authentication functions of a UE Profile from its K and OPc or TOPc
+func profileAlgorithm(ue *models.UeProfile) (aka.Algorithm, error) {
+	opcHex, err := ProfileOpc(ue)
+	if err != nil {
+		return nil, err
+	}
+	k, err := hex.DecodeString(ue.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid K of SUPI %s: %v", ue.Supi, err)
+	}
+	opc, err := hex.DecodeString(opcHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPc of SUPI %s: %v", ue.Supi, err)
+	}
+
+	if utils.AuthAlgorithm(ue) == utils.TUAK {
+		return tuak.NewWithTopc(k, opc, tuak.DefaultParams)
+	}
+	return milenage.NewWithOpc(k, opc)
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/utils"
+	"errors"
+	"testing"
+)
+
+func TestInvalidAuthRequestKeepsSqn(t *testing.T) {
+	ue := &models.UeProfile{
+		Supi:   "imsi-208930000000001",
+		PlmnId: models.PlmnId{Mcc: "208", Mnc: "93"},
+		Key:    "465b5ce8b199b49faa5f0a2ee238a6bc",
+		Op:     "cdc202d5123e20f62b6d676ac72cb318",
+		OpType: utils.OPC,
+		Amf:    utils.DEFAULT_AMF,
+	}
+	taken := 0
+	sqnSource := func() ([]byte, error) {
+		taken++
+		return decodeSqn("000000000021")
+	}
+
+	for name, req := range map[string]models.AuthVectorRequest{
+		"malformed RAND":               {Rand: "zz"},
+		"short RAND":                   {Rand: "23553cbe9637a89d"},
+		"invalid serving network name": {ServingNetworkName: "mnc093.mcc208.3gppnetwork.org"},
+	} {
+		var requestErr *RequestError
+		if _, err := profileAuthVector(ue, req, sqnSource); !errors.As(err, &requestErr) {
+			t.Errorf("%s: error %v, want a RequestError", name, err)
+		}
+	}
+	badAmf := *ue
+	badAmf.Amf = "80"
+	if _, err := profileAuthVector(&badAmf, models.AuthVectorRequest{}, sqnSource); err == nil {
+		t.Error("authentication vector generated with a short AMF")
+	}
+	if _, err := profileEapAkaPrimeKeys(ue, models.EapAkaPrimeRequest{}, sqnSource); err == nil {
+		t.Error("EAP-AKA' keys derived without a network name")
+	}
+	if taken != 0 {
+		t.Fatalf("invalid requests took %d SQN(s)", taken)
+	}
+
+	if _, err := profileAuthVector(ue, models.AuthVectorRequest{}, sqnSource); err != nil || taken != 1 {
+		t.Fatalf("valid request: %v, took %d SQN(s)", err, taken)
+	}
+}
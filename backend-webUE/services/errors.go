@@ -0,0 +1,24 @@
+// services/errors.go
+package services
+
+// RequestError is an error in the input of a request, as opposed to a failure of a service
+// or of the stored data it works on. The API answers it with 400 Bad Request.
+type RequestError struct {
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// invalidRequest marks an error as caused by the request
+func invalidRequest(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RequestError{Err: err}
+}
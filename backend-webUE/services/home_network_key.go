@@ -0,0 +1,427 @@
+package services
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/secrets"
+	"backend-webUE/supi-key"
+	"backend-webUE/utils"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HomeNetworkKeyService is the registry of home network key pairs used for SUCI protection
+type HomeNetworkKeyService struct {
+	collection       *mongo.Collection
+	ueProfileService *UeProfileService
+	// keyring encrypts the private keys at rest; without one they are stored in clear
+	keyring *secrets.Keyring
+}
+
+// NewHomeNetworkKeyService creates a new HomeNetworkKeyService. Private keys are encrypted with
+// the master keys of MASTER_KEY_FILE; without it they are stored in clear.
+func NewHomeNetworkKeyService(db *mongo.Database, ueProfileService *UeProfileService) *HomeNetworkKeyService {
+	collection := db.Collection("home_network_keys")
+
+	// Key IDs identify a key pair in every SUCI, so they must stay unique
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "keyId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Error creating home network key index: %v", err)
+	}
+
+	return &HomeNetworkKeyService{
+		collection:       collection,
+		ueProfileService: ueProfileService,
+		keyring:          envKeyring(),
+	}
+}
+
+// SetKeyring replaces the master keys that encrypt the private keys at rest
+func (s *HomeNetworkKeyService) SetKeyring(keyring *secrets.Keyring) {
+	s.keyring = keyring
+}
+
+// homeNetworkKeyOwner names the owner an encrypted private key is bound to
+func homeNetworkKeyOwner(keyId int) string {
+	return fmt.Sprintf("home-network-key-%d", keyId)
+}
+
+// openPrivateKey decrypts the private key of a stored home network key in place
+func (s *HomeNetworkKeyService) openPrivateKey(key *models.HomeNetworkKey) error {
+	privateKey, err := s.keyring.Decrypt(key.PrivateKey, homeNetworkKeyOwner(key.KeyId), secretFieldPrivateKey)
+	if err != nil {
+		log.Printf("Error decrypting home network key %d: %v", key.KeyId, err)
+		return err
+	}
+	key.PrivateKey = privateKey
+	return nil
+}
+
+// CreateKey generates a new key pair for the scheme and registers it as active
+func (s *HomeNetworkKeyService) CreateKey(scheme int) (*models.HomeNetworkKey, error) {
+	var curve supi.EllipticCurve
+	var err error
+	switch scheme {
+	case utils.A_SCHEME:
+		curve, err = supi.NewX25519("")
+	case utils.B_SCHEME:
+		curve, err = supi.NewSecp256r1("")
+	default:
+		return nil, fmt.Errorf("unsupported profile scheme: %d", scheme)
+	}
+	if err != nil {
+		log.Printf("Error generating home network key: %v", err)
+		return nil, err
+	}
+	return s.register(scheme, 0, hex.EncodeToString(curve.GetPrivKey()), hex.EncodeToString(curve.GetPubKey()))
+}
+
+// ImportKey registers an existing private key for the scheme as active. A keyId of 0
+// assigns the lowest free key ID.
+func (s *HomeNetworkKeyService) ImportKey(scheme int, keyId int, privateKey string) (*models.HomeNetworkKey, error) {
+	var curve supi.EllipticCurve
+	var err error
+	switch scheme {
+	case utils.A_SCHEME:
+		curve, err = supi.NewX25519(privateKey)
+	case utils.B_SCHEME:
+		curve, err = supi.NewSecp256r1(privateKey)
+	default:
+		return nil, fmt.Errorf("unsupported profile scheme: %d", scheme)
+	}
+	if err != nil {
+		log.Printf("Error importing home network key: %v", err)
+		return nil, err
+	}
+	return s.register(scheme, keyId, hex.EncodeToString(curve.GetPrivKey()), hex.EncodeToString(curve.GetPubKey()))
+}
+
+func (s *HomeNetworkKeyService) register(scheme int, keyId int, privateKey, publicKey string) (*models.HomeNetworkKey, error) {
+	if keyId == 0 {
+		var err error
+		if keyId, err = s.freeKeyId(); err != nil {
+			return nil, err
+		}
+	} else if err := utils.ValidateHomeNetworkKeyId(scheme, keyId); err != nil {
+		return nil, err
+	}
+
+	key := &models.HomeNetworkKey{
+		KeyId:      keyId,
+		Scheme:     scheme,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+		Status:     models.HomeNetworkKeyActive,
+		CreatedAt:  time.Now(),
+	}
+	sealed := *key
+	var err error
+	if sealed.PrivateKey, err = s.keyring.Encrypt(privateKey, homeNetworkKeyOwner(keyId), secretFieldPrivateKey); err != nil {
+		log.Printf("Error encrypting home network key %d: %v", keyId, err)
+		return nil, err
+	}
+	result, err := s.collection.InsertOne(context.Background(), sealed)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("home network public key ID %d is already registered", keyId)
+		}
+		log.Printf("Error inserting home network key: %v", err)
+		return nil, err
+	}
+	key.ID, _ = result.InsertedID.(primitive.ObjectID)
+	return key, nil
+}
+
+// freeKeyId returns the lowest key ID that is not registered yet
+func (s *HomeNetworkKeyService) freeKeyId() (int, error) {
+	keys, err := s.GetAllKeys()
+	if err != nil {
+		return 0, err
+	}
+	used := make(map[int]bool)
+	for _, key := range keys {
+		used[key.KeyId] = true
+	}
+	for keyId := utils.MinHomeNetworkKeyId; keyId <= utils.MaxHomeNetworkKeyId; keyId++ {
+		if !used[keyId] {
+			return keyId, nil
+		}
+	}
+	return 0, fmt.Errorf("all home network public key IDs are in use")
+}
+
+// GetAllKeys retrieves all registered home network keys
+func (s *HomeNetworkKeyService) GetAllKeys() ([]models.HomeNetworkKey, error) {
+	cursor, err := s.collection.Find(context.Background(), bson.M{})
+	if err != nil {
+		log.Printf("Error fetching home network keys: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var keys []models.HomeNetworkKey
+	if err := cursor.All(context.Background(), &keys); err != nil {
+		log.Printf("Error decoding home network keys: %v", err)
+		return nil, err
+	}
+	for i := range keys {
+		if err := s.openPrivateKey(&keys[i]); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// GetKey retrieves a home network key by its key ID
+func (s *HomeNetworkKeyService) GetKey(keyId int) (*models.HomeNetworkKey, error) {
+	var key models.HomeNetworkKey
+	err := s.collection.FindOne(context.Background(), bson.M{"keyId": keyId}).Decode(&key)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error fetching home network key %d: %v", keyId, err)
+		}
+		return nil, err
+	}
+	if err := s.openPrivateKey(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ActiveKey retrieves the newest active key of the scheme
+func (s *HomeNetworkKeyService) ActiveKey(scheme int) (*models.HomeNetworkKey, error) {
+	var key models.HomeNetworkKey
+	opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	err := s.collection.FindOne(context.Background(), bson.M{"scheme": scheme, "status": models.HomeNetworkKeyActive}, opts).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("no active home network key for scheme %d", scheme)
+		}
+		log.Printf("Error fetching active home network key: %v", err)
+		return nil, err
+	}
+	if err := s.openPrivateKey(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RetireKey marks a key as retired. Retired keys are no longer handed out for new UEs
+// but still de-conceal SUCIs that were computed with them.
+func (s *HomeNetworkKeyService) RetireKey(keyId int) error {
+	update := bson.M{
+		"$set": bson.M{"status": models.HomeNetworkKeyRetired, "retiredAt": time.Now()},
+	}
+	result, err := s.collection.UpdateOne(context.Background(), bson.M{"keyId": keyId}, update)
+	if err != nil {
+		log.Printf("Error retiring home network key %d: %v", keyId, err)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RotateKey creates a new active key for the scheme, recomputes the SUCI of every UE Profile that
+// referenced the previously active keys of the scheme with the new key, then retires those keys.
+// The old keys stay active until every UE Profile has moved, so a failed rotation can be rerun.
+// It returns the new key and the number of UE Profiles moved to it.
+func (s *HomeNetworkKeyService) RotateKey(scheme int) (*models.HomeNetworkKey, int, error) {
+	keys, err := s.GetAllKeys()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	newKey, err := s.CreateKey(scheme)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	previous := make(map[int]bool)
+	for _, key := range keys {
+		if key.Scheme == scheme && key.Status == models.HomeNetworkKeyActive {
+			previous[key.KeyId] = true
+		}
+	}
+	moved, err := s.moveUeProfiles(scheme, previous, newKey)
+	if err != nil {
+		return newKey, moved, err
+	}
+
+	for keyId := range previous {
+		if err := s.RetireKey(keyId); err != nil {
+			return newKey, moved, err
+		}
+	}
+	return newKey, moved, nil
+}
+
+// moveUeProfiles points the UE Profiles that use one of the previous keys at newKey and recomputes
+// their SUCI. Profiles are written without validation: those stored before UE Profiles were
+// validated, like ones with an IMEI lacking its check digit, move like any other.
+func (s *HomeNetworkKeyService) moveUeProfiles(scheme int, previous map[int]bool, newKey *models.HomeNetworkKey) (int, error) {
+	profiles, err := s.ueProfileService.GetAllUEProfiles()
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for i := range profiles {
+		ue := &profiles[i]
+		if ue.ProtectionScheme != scheme || !previous[ue.HomeNetworkPublicKeyId] {
+			continue
+		}
+
+		ue.HomeNetworkPublicKeyId = newKey.KeyId
+		ue.HomeNetworkPublicKey = newKey.PublicKey
+		suci, err := utils.RecomputeSuci(ue)
+		if err != nil {
+			log.Printf("Error recomputing SUCI for SUPI %s: %v", ue.Supi, err)
+			return moved, err
+		}
+		ue.Suci = suci
+		ue.UpdatedAt = time.Now()
+
+		if err := s.ueProfileService.updateUeProfile(ue.Supi, ue); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// Deconceal recovers the SUPI of a SUCI with the registered private key of its key ID
+func (s *HomeNetworkKeyService) Deconceal(suciString string) (string, error) {
+	suci, err := supi.ParseSuci(suciString)
+	if err != nil {
+		return "", err
+	}
+	if suci.ProtectionSchemeId == supi.NullSchemeId {
+		return supi.Suci2Supi(suciString, "")
+	}
+
+	key, err := s.GetKey(suci.HomeNetworkPublicKeyId)
+	if err != nil {
+		return "", fmt.Errorf("unknown home network public key ID %d: %v", suci.HomeNetworkPublicKeyId, err)
+	}
+	if key.Scheme != suci.ProtectionSchemeId {
+		return "", fmt.Errorf("home network public key ID %d belongs to scheme %d, not %d", key.KeyId, key.Scheme, suci.ProtectionSchemeId)
+	}
+	return supi.Suci2Supi(suciString, key.PrivateKey)
+}
+
+// legacyProfileKey is a home network private key still embedded in a stored UE Profile. It is
+// read from the document, as UE Profiles no longer carry the private key.
+type legacyProfileKey struct {
+	Supi       string `bson:"supi"`
+	PrivateKey string `bson:"homenetworkprivatekey"`
+}
+
+// MigrateUeProfileKeys moves the home network private keys still embedded in UE Profiles
+// into the registry and removes them from the profile documents. A key whose ID is already
+// taken by a different key is registered under a free ID and its UE Profiles get a new SUCI.
+// It returns the number of keys imported and of UE Profiles stripped.
+func (s *HomeNetworkKeyService) MigrateUeProfileKeys() (int, int, error) {
+	var legacyKeys []legacyProfileKey
+	filter := bson.M{"homenetworkprivatekey": bson.M{"$nin": bson.A{"", nil}}}
+	cursor, err := s.ueProfileService.collection.Find(context.Background(), filter,
+		options.Find().SetProjection(bson.M{"supi": 1, "homenetworkprivatekey": 1}))
+	if err != nil {
+		log.Printf("Error fetching UE Profiles with a home network private key: %v", err)
+		return 0, 0, err
+	}
+	defer cursor.Close(context.Background())
+	if err := cursor.All(context.Background(), &legacyKeys); err != nil {
+		log.Printf("Error decoding UE Profiles with a home network private key: %v", err)
+		return 0, 0, err
+	}
+
+	imported, stripped := 0, 0
+	// registered maps "scheme/private key" to the key it was registered as
+	registered := make(map[string]*models.HomeNetworkKey)
+	for _, legacy := range legacyKeys {
+		ue, err := s.ueProfileService.GetUeProfile(legacy.Supi)
+		if err != nil {
+			return imported, stripped, err
+		}
+
+		if ue.ProtectionScheme != utils.NULL_SCHEME {
+			id := fmt.Sprintf("%d/%s", ue.ProtectionScheme, strings.ToLower(legacy.PrivateKey))
+			key, ok := registered[id]
+			if !ok {
+				var isNew bool
+				key, isNew, err = s.registerLegacyKey(ue.ProtectionScheme, ue.HomeNetworkPublicKeyId, legacy.PrivateKey)
+				if err != nil {
+					log.Printf("Error migrating home network key of SUPI %s: %v", ue.Supi, err)
+					return imported, stripped, err
+				}
+				if isNew {
+					imported++
+				}
+				registered[id] = key
+			}
+
+			if key.KeyId != ue.HomeNetworkPublicKeyId {
+				ue.HomeNetworkPublicKeyId = key.KeyId
+				ue.HomeNetworkPublicKey = key.PublicKey
+				if ue.Suci, err = utils.RecomputeSuci(ue); err != nil {
+					log.Printf("Error recomputing SUCI for SUPI %s: %v", ue.Supi, err)
+					return imported, stripped, err
+				}
+				if err := s.ueProfileService.updateUeProfile(ue.Supi, ue); err != nil {
+					return imported, stripped, err
+				}
+			}
+		}
+
+		update := bson.M{"$unset": bson.M{"homenetworkprivatekey": ""}}
+		if _, err := s.ueProfileService.collection.UpdateOne(context.Background(), bson.M{"supi": ue.Supi}, update); err != nil {
+			log.Printf("Error removing the home network private key of SUPI %s: %v", ue.Supi, err)
+			return imported, stripped, err
+		}
+		stripped++
+	}
+	return imported, stripped, nil
+}
+
+// registerLegacyKey finds or imports the registry entry for a private key taken from a UE Profile.
+// It reports whether the key was newly imported.
+func (s *HomeNetworkKeyService) registerLegacyKey(scheme int, keyId int, privateKey string) (*models.HomeNetworkKey, bool, error) {
+	keys, err := s.GetAllKeys()
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range keys {
+		if keys[i].Scheme == scheme && strings.EqualFold(keys[i].PrivateKey, privateKey) {
+			return &keys[i], false, nil
+		}
+	}
+
+	// Keep the UE's key ID when it is free so its SUCI stays valid
+	if keyId < utils.MinHomeNetworkKeyId || keyId > utils.MaxHomeNetworkKeyId {
+		keyId = 0
+	}
+	for _, key := range keys {
+		if key.KeyId == keyId {
+			keyId = 0
+			break
+		}
+	}
+	key, err := s.ImportKey(scheme, keyId, privateKey)
+	if err != nil {
+		return nil, false, err
+	}
+	return key, true, nil
+}
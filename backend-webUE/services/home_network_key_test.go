@@ -0,0 +1,82 @@
+package services
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/supi-key"
+	"backend-webUE/utils"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const testProfileAPublicKey = "5a8d38864820197c3394b92613b20b91633cbd897119273bf8e4a6f4eec0a650"
+
+// legacyUeProfile is a UE Profile as the generator stored it before UE Profiles were validated:
+// its IMEI is 15 random digits without a Luhn check digit
+func legacyUeProfile(t *testing.T) *models.UeProfile {
+	ue := &models.UeProfile{
+		Supi:                   "imsi-208930000000001",
+		PlmnId:                 models.PlmnId{Mcc: "208", Mnc: "93"},
+		RoutingIndicator:       utils.DEFAULT_ROUTING_INDICATOR,
+		ProtectionScheme:       utils.A_SCHEME,
+		HomeNetworkPublicKeyId: 1,
+		HomeNetworkPublicKey:   testProfileAPublicKey,
+		Key:                    "20eb84b082221fd50babe767c76cda8b",
+		Op:                     "41207e7b6bc1c81e38027c0a1e9ef44e",
+		OpType:                 utils.OPC,
+		Imei:                   "858277190518048",
+	}
+	var err error
+	if ue.Suci, err = utils.RecomputeSuci(ue); err != nil {
+		t.Fatalf("RecomputeSuci failed: %v", err)
+	}
+	errs := utils.ValidateUeProfile(ue)
+	if len(errs) != 1 || errs[0].Field != "imei" {
+		t.Fatalf("legacy UE Profile field errors = %v, want only the IMEI rejected", errs)
+	}
+	return ue
+}
+
+func TestMoveUeProfilesKeepsLegacyProfiles(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("non-Luhn IMEI", func(mt *mtest.T) {
+		s := &HomeNetworkKeyService{ueProfileService: &UeProfileService{collection: mt.Coll}}
+		legacy := legacyUeProfile(mt.T)
+		raw, err := bson.Marshal(legacy)
+		if err != nil {
+			mt.Fatalf("bson.Marshal failed: %v", err)
+		}
+		var doc bson.D
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			mt.Fatalf("bson.Unmarshal failed: %v", err)
+		}
+		namespace := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, namespace, mtest.FirstBatch, doc),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+
+		newKey := &models.HomeNetworkKey{KeyId: 2, Scheme: utils.A_SCHEME, PublicKey: testProfileAPublicKey}
+		moved, err := s.moveUeProfiles(utils.A_SCHEME, map[int]bool{1: true}, newKey)
+		if err != nil || moved != 1 {
+			mt.Fatalf("moveUeProfiles = %d, %v, want the legacy UE Profile moved", moved, err)
+		}
+
+		update := mt.GetStartedEvent()
+		for update != nil && update.CommandName != "update" {
+			update = mt.GetStartedEvent()
+		}
+		if update == nil {
+			mt.Fatal("moveUeProfiles sent no update")
+		}
+		set := update.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set").Document()
+		suci, err := supi.ParseSuci(set.Lookup("suci").StringValue())
+		if err != nil || suci.HomeNetworkPublicKeyId != 2 {
+			mt.Fatalf("moved SUCI = %v, %v, want key ID 2", set.Lookup("suci"), err)
+		}
+		if imei := set.Lookup("imei").StringValue(); imei != legacy.Imei {
+			mt.Fatalf("moved IMEI = %s, want %s", imei, legacy.Imei)
+		}
+	})
+}
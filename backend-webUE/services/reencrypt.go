@@ -0,0 +1,110 @@
+// services/reencrypt.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReencryptSecrets encrypts the K and OP of every UE Profile with the active master key, including
+// the ones still stored in clear, after a master key rotation. It returns the number of UE Profiles rewritten.
+func (s *UeProfileService) ReencryptSecrets() (int, error) {
+	if s.keyring == nil {
+		return 0, fmt.Errorf("no master key is configured")
+	}
+
+	var docs []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Supi string             `bson:"supi"`
+		Key  string             `bson:"key"`
+		Op   string             `bson:"op"`
+	}
+	if err := findAll(s.collection, bson.M{"supi": 1, "key": 1, "op": 1}, &docs); err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	for _, doc := range docs {
+		key, keyChanged, err := s.keyring.Reencrypt(doc.Key, doc.Supi, secretFieldKey)
+		if err != nil {
+			return rewritten, fmt.Errorf("K of SUPI %s: %v", doc.Supi, err)
+		}
+		op, opChanged, err := s.keyring.Reencrypt(doc.Op, doc.Supi, secretFieldOp)
+		if err != nil {
+			return rewritten, fmt.Errorf("OP of SUPI %s: %v", doc.Supi, err)
+		}
+		if !keyChanged && !opChanged {
+			continue
+		}
+
+		// Only replace the values that were read, a concurrent update already wrote fresh ones
+		filter := bson.M{"_id": doc.ID, "key": doc.Key, "op": doc.Op}
+		update := bson.M{"$set": bson.M{"key": key, "op": op}}
+		result, err := s.collection.UpdateOne(context.Background(), filter, update)
+		if err != nil {
+			log.Printf("Error re-encrypting UE Profile %s: %v", doc.Supi, err)
+			return rewritten, err
+		}
+		rewritten += int(result.ModifiedCount)
+	}
+	return rewritten, nil
+}
+
+// ReencryptPrivateKeys encrypts the private key of every registered home network key with the active
+// master key, including the ones still stored in clear. It returns the number of keys rewritten.
+func (s *HomeNetworkKeyService) ReencryptPrivateKeys() (int, error) {
+	if s.keyring == nil {
+		return 0, fmt.Errorf("no master key is configured")
+	}
+
+	var docs []struct {
+		ID         primitive.ObjectID `bson:"_id"`
+		KeyId      int                `bson:"keyId"`
+		PrivateKey string             `bson:"privateKey"`
+	}
+	if err := findAll(s.collection, bson.M{"keyId": 1, "privateKey": 1}, &docs); err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	for _, doc := range docs {
+		privateKey, changed, err := s.keyring.Reencrypt(doc.PrivateKey, homeNetworkKeyOwner(doc.KeyId), secretFieldPrivateKey)
+		if err != nil {
+			return rewritten, fmt.Errorf("home network key %d: %v", doc.KeyId, err)
+		}
+		if !changed {
+			continue
+		}
+
+		filter := bson.M{"_id": doc.ID, "privateKey": doc.PrivateKey}
+		update := bson.M{"$set": bson.M{"privateKey": privateKey}}
+		result, err := s.collection.UpdateOne(context.Background(), filter, update)
+		if err != nil {
+			log.Printf("Error re-encrypting home network key %d: %v", doc.KeyId, err)
+			return rewritten, err
+		}
+		rewritten += int(result.ModifiedCount)
+	}
+	return rewritten, nil
+}
+
+// findAll decodes the projected fields of every document of a collection
+func findAll(collection *mongo.Collection, projection bson.M, docs interface{}) error {
+	cursor, err := collection.Find(context.Background(), bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		log.Printf("Error fetching %s: %v", collection.Name(), err)
+		return err
+	}
+	defer cursor.Close(context.Background())
+	if err := cursor.All(context.Background(), docs); err != nil {
+		log.Printf("Error decoding %s: %v", collection.Name(), err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// services/sqn.go
+package services
+
+import (
+	"backend-webUE/aka"
+	"backend-webUE/models"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxSqnUpdateAttempts bounds the retries of a concurrent SQN update
+const maxSqnUpdateAttempts = 10
+
+// GetSqn returns the sequence number state of a UE Profile. Profiles that never had a
+// vector generated start from SQN 0.
+func (s *UeProfileService) GetSqn(supi string) (*models.SqnState, error) {
+	sqn, _, err := s.loadSqn(supi)
+	if err != nil {
+		return nil, err
+	}
+	return newSqnState(supi, sqn), nil
+}
+
+// SetSqn overwrites the sequence number state of a UE Profile with a hex SQN
+func (s *UeProfileService) SetSqn(supi string, sqnHex string) (*models.SqnState, error) {
+	sqn, err := parseSqn(sqnHex)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storeSqn(supi, sqn); err != nil {
+		return nil, err
+	}
+	return newSqnState(supi, sqn), nil
+}
+
+// Resynchronise recovers SQN_MS from the AUTS a UE returned and moves the UE's sequence number
+// state to it, so the next vector is fresh for the UE (TS 33.102 clause 6.3.5)
+func (s *UeProfileService) Resynchronise(supi string, req models.ResyncRequest) (*models.SqnState, error) {
+	ue, err := s.GetUeProfile(supi)
+	if err != nil {
+		return nil, err
+	}
+	alg, err := profileAlgorithm(ue)
+	if err != nil {
+		return nil, err
+	}
+
+	rand, err := hex.DecodeString(req.Rand)
+	if err != nil {
+		return nil, invalidRequest(fmt.Errorf("invalid RAND: %v", err))
+	}
+	auts, err := hex.DecodeString(req.Auts)
+	if err != nil {
+		return nil, invalidRequest(fmt.Errorf("invalid AUTS: %v", err))
+	}
+	sqnMs, err := aka.Resynchronise(alg, rand, auts)
+	if err != nil {
+		log.Printf("Error resynchronising SUPI %s: %v", supi, err)
+		return nil, invalidRequest(err)
+	}
+
+	sqn, _ := aka.SqnToUint64(sqnMs)
+	if err := s.storeSqn(supi, sqn); err != nil {
+		return nil, err
+	}
+	return newSqnState(supi, sqn), nil
+}
+
+// nextSqn advances the sequence number state of a UE Profile and returns the new SQN.
+// Concurrent callers never get the same SQN: the update only applies to the state it was computed from.
+func (s *UeProfileService) nextSqn(supi string) ([]byte, error) {
+	for attempt := 0; attempt < maxSqnUpdateAttempts; attempt++ {
+		current, stored, err := s.loadSqn(supi)
+		if err != nil {
+			return nil, err
+		}
+		next := aka.NextSqn(current)
+
+		filter := bson.M{"supi": supi, "sqn": hex.EncodeToString(aka.Uint64ToSqn(current))}
+		if !stored {
+			filter["sqn"] = bson.M{"$exists": false}
+		}
+		update := bson.M{"$set": bson.M{"sqn": hex.EncodeToString(aka.Uint64ToSqn(next))}}
+		result, err := s.collection.UpdateOne(context.Background(), filter, update)
+		if err != nil {
+			log.Printf("Error updating SQN for SUPI %s: %v", supi, err)
+			return nil, err
+		}
+		if result.MatchedCount == 1 {
+			return aka.Uint64ToSqn(next), nil
+		}
+	}
+	return nil, fmt.Errorf("SQN of SUPI %s changed concurrently, giving up", supi)
+}
+
+// loadSqn reads the SQN stored on a UE Profile document and reports whether one was stored
+func (s *UeProfileService) loadSqn(supi string) (uint64, bool, error) {
+	var doc struct {
+		Sqn *string `bson:"sqn"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"sqn": 1})
+	if err := s.collection.FindOne(context.Background(), bson.M{"supi": supi}, opts).Decode(&doc); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error fetching SQN: %v", err)
+		}
+		return 0, false, err
+	}
+	if doc.Sqn == nil {
+		return 0, false, nil
+	}
+	sqn, err := parseSqn(*doc.Sqn)
+	if err != nil {
+		return 0, false, fmt.Errorf("stored SQN of SUPI %s: %v", supi, err)
+	}
+	return sqn, true, nil
+}
+
+// storeSqn sets the SQN stored on a UE Profile document
+func (s *UeProfileService) storeSqn(supi string, sqn uint64) error {
+	update := bson.M{"$set": bson.M{"sqn": hex.EncodeToString(aka.Uint64ToSqn(sqn))}}
+	result, err := s.collection.UpdateOne(context.Background(), bson.M{"supi": supi}, update)
+	if err != nil {
+		log.Printf("Error updating SQN for SUPI %s: %v", supi, err)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		log.Printf("No UE Profile found with SUPI: %s", supi)
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func parseSqn(sqnHex string) (uint64, error) {
+	b, err := hex.DecodeString(sqnHex)
+	if err != nil {
+		return 0, fmt.Errorf("invalid SQN: %v", err)
+	}
+	return aka.SqnToUint64(b)
+}
+
+func newSqnState(supi string, sqn uint64) *models.SqnState {
+	return &models.SqnState{
+		Supi: supi,
+		Sqn:  hex.EncodeToString(aka.Uint64ToSqn(sqn)),
+		Seq:  sqn >> aka.IndLen,
+		Ind:  int(sqn & (1<<aka.IndLen - 1)),
+	}
+}
@@ -2,33 +2,144 @@
 package services
 
 import (
+	"backend-webUE/milenage"
 	"backend-webUE/models"
+	"backend-webUE/secrets"
+	"backend-webUE/supi-key"
+	"backend-webUE/tuak"
 	"backend-webUE/utils"
 	"context"
+	"fmt"
 	"log"
+	"reflect"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// MalformedSuci reports a stored UE Profile whose SUCI cannot be parsed
+type MalformedSuci struct {
+	Supi  string `json:"supi"`
+	Suci  string `json:"suci"`
+	Error string `json:"error"`
+}
+
 // UeProfileService provides methods to interact with UE Profiles in the database
 type UeProfileService struct {
 	collection *mongo.Collection
 	operator   *utils.Operator
+	// keyring encrypts K and OP at rest; without one they are stored in clear
+	keyring *secrets.Keyring
 }
 
-// NewUeProfileService creates a new UeProfileService
+// NewUeProfileService creates a new UeProfileService. K and OP are encrypted with the master
+// keys of MASTER_KEY_FILE; without it they are stored in clear.
 func NewUeProfileService(db *mongo.Database, operator *utils.Operator) *UeProfileService {
+	collection := db.Collection("ue_profiles")
+
+	// A SUPI identifies one subscriber, so it must stay unique
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "supi", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Error creating UE Profile SUPI index: %v", err)
+	}
+
 	return &UeProfileService{
-		collection: db.Collection("ue_profiles"),
+		collection: collection,
 		operator:   operator,
+		keyring:    envKeyring(),
 	}
 }
 
+// SetKeyring replaces the master keys that encrypt K and OP at rest
+func (s *UeProfileService) SetKeyring(keyring *secrets.Keyring) {
+	s.keyring = keyring
+}
+
+// envKeyring loads the master keys named by MASTER_KEY_FILE. A master key file that cannot be
+// read stops the process rather than letting secrets be stored in clear.
+func envKeyring() *secrets.Keyring {
+	keyring, err := secrets.LoadKeyringFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load the master key: %v", err)
+	}
+	if keyring == nil {
+		log.Printf("%s is not set: secrets are stored in clear", secrets.MasterKeyFileEnv)
+	}
+	return keyring
+}
+
+// Fields that encrypted secrets are bound to, along with the SUPI or key ID that owns them
+const (
+	secretFieldKey        = "key"
+	secretFieldOp         = "op"
+	secretFieldPrivateKey = "privateKey"
+)
+
+// sealSecrets returns a copy of a UE Profile with K and OP encrypted for storage
+func (s *UeProfileService) sealSecrets(ue *models.UeProfile) (*models.UeProfile, error) {
+	sealed := *ue
+	var err error
+	if sealed.Key, err = s.keyring.Encrypt(ue.Key, ue.Supi, secretFieldKey); err != nil {
+		log.Printf("Error encrypting K of SUPI %s: %v", ue.Supi, err)
+		return nil, err
+	}
+	if sealed.Op, err = s.keyring.Encrypt(ue.Op, ue.Supi, secretFieldOp); err != nil {
+		log.Printf("Error encrypting OP of SUPI %s: %v", ue.Supi, err)
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// openSecrets decrypts the K and OP of a stored UE Profile in place
+func (s *UeProfileService) openSecrets(ue *models.UeProfile) error {
+	var err error
+	if ue.Key, err = s.keyring.Decrypt(ue.Key, ue.Supi, secretFieldKey); err != nil {
+		log.Printf("Error decrypting K of SUPI %s: %v", ue.Supi, err)
+		return err
+	}
+	if ue.Op, err = s.keyring.Decrypt(ue.Op, ue.Supi, secretFieldOp); err != nil {
+		log.Printf("Error decrypting OP of SUPI %s: %v", ue.Supi, err)
+		return err
+	}
+	return nil
+}
+
+// ValidateUeProfile validates a UE Profile without writing it and reports every field error
+func (s *UeProfileService) ValidateUeProfile(ue *models.UeProfile) *models.ValidationReport {
+	errs := utils.ValidateUeProfile(ue)
+	return &models.ValidationReport{
+		Valid:  len(errs) == 0,
+		Errors: append([]models.FieldError{}, errs...),
+	}
+}
+
+// checkUeProfile validates a UE Profile before a write, returning its field errors as a
+// RequestError wrapping utils.ValidationErrors, and stores its SDs in their stored form
+func checkUeProfile(ue *models.UeProfile) error {
+	if errs := utils.ValidateUeProfile(ue); len(errs) > 0 {
+		return invalidRequest(errs)
+	}
+	return utils.NormalizeUeSds(ue)
+}
+
 // InsertUEProfile inserts a single UE Profile into the database
 func (s *UeProfileService) InsertUEProfile(ue *models.UeProfile) error {
-	_, err := s.collection.InsertOne(context.Background(), ue)
+	if err := checkUeProfile(ue); err != nil {
+		return err
+	}
+	sealed, err := s.sealSecrets(ue)
 	if err != nil {
+		return err
+	}
+	_, err = s.collection.InsertOne(context.Background(), sealed)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return &utils.SupiCollisionError{Supi: ue.Supi}
+		}
 		log.Printf("Error inserting UE Profile: %v", err)
 		return err
 	}
@@ -39,17 +150,61 @@ func (s *UeProfileService) InsertUEProfile(ue *models.UeProfile) error {
 func (s *UeProfileService) InsertUEProfiles(profiles []models.UeProfile) error {
 	var docs []interface{}
 	for _, profile := range profiles {
-		docs = append(docs, profile)
+		if err := checkUeProfile(&profile); err != nil {
+			return fmt.Errorf("SUPI %s: %w", profile.Supi, err)
+		}
+		sealed, err := s.sealSecrets(&profile)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, sealed)
 	}
 	_, err := s.collection.InsertMany(context.Background(), docs)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("a SUPI of the batch already exists: %v", err)
+		}
 		log.Printf("Error inserting multiple UE Profiles: %v", err)
 		return err
 	}
 	return nil
 }
 
-// GetAllUEProfiles retrieves all UE Profiles from the database
+// GenerateUEProfiles generates count UE Profiles with the given operator configuration and inserts them.
+// A configuration with a Seed always generates the same SUPIs, keys, IMEIs and profile assignments.
+// A Seed outside test mode is a RequestError.
+// SUPIs already in ue_profiles are skipped and returned, unless the configuration reports them as errors.
+func (s *UeProfileService) GenerateUEProfiles(config *utils.OperatorConfig, count int) ([]models.UeProfile, []string, error) {
+	if config.Seed != nil && !utils.TestMode() {
+		return nil, nil, invalidRequest(utils.ErrSeedOutsideTestMode)
+	}
+	cfg := *config
+	if cfg.SupiRegistry == nil {
+		cfg.SupiRegistry = s
+	}
+	operator := utils.NewOperator(&cfg)
+	profiles, err := operator.GenerateUes(count)
+	if err != nil {
+		log.Printf("Error generating UE Profiles: %v", err)
+		return nil, nil, err
+	}
+	if err := s.InsertUEProfiles(profiles); err != nil {
+		return nil, nil, err
+	}
+	return profiles, operator.SkippedSupis(), nil
+}
+
+// SupiExists reports whether a UE Profile with the SUPI is stored
+func (s *UeProfileService) SupiExists(supi string) (bool, error) {
+	count, err := s.collection.CountDocuments(context.Background(), bson.M{"supi": supi}, options.Count().SetLimit(1))
+	if err != nil {
+		log.Printf("Error checking SUPI %s: %v", supi, err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetAllUEProfiles retrieves all UE Profiles from the database with K and OP decrypted
 func (s *UeProfileService) GetAllUEProfiles() ([]models.UeProfile, error) {
 	cursor, err := s.collection.Find(context.Background(), bson.M{})
 	if err != nil {
@@ -65,6 +220,9 @@ func (s *UeProfileService) GetAllUEProfiles() ([]models.UeProfile, error) {
 			log.Printf("Error decoding UE Profile: %v", err)
 			return nil, err
 		}
+		if err := s.openSecrets(&profile); err != nil {
+			return nil, err
+		}
 		profiles = append(profiles, profile)
 	}
 
@@ -76,13 +234,75 @@ func (s *UeProfileService) GetAllUEProfiles() ([]models.UeProfile, error) {
 	return profiles, nil
 }
 
-// UpdateUeProfile updates an existing UE Profile based on SUPI
+// GetUeProfile retrieves a single UE Profile based on SUPI
+func (s *UeProfileService) GetUeProfile(supi string) (*models.UeProfile, error) {
+	var ue models.UeProfile
+	err := s.collection.FindOne(context.Background(), bson.M{"supi": supi}).Decode(&ue)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error fetching UE Profile: %v", err)
+		}
+		return nil, err
+	}
+	if err := s.openSecrets(&ue); err != nil {
+		return nil, err
+	}
+	return &ue, nil
+}
+
+// GetOpc returns the OPc of a UE Profile, or its TOPc for TUAK, deriving it from K and OP/TOP
+// when the profile stores OP/TOP
+func (s *UeProfileService) GetOpc(supi string) (string, error) {
+	ue, err := s.GetUeProfile(supi)
+	if err != nil {
+		return "", err
+	}
+	return ProfileOpc(ue)
+}
+
+// ProfileOpc returns the OPc of a UE Profile, or its TOPc for TUAK, deriving it from K and OP/TOP
+// when the profile stores OP/TOP
+func ProfileOpc(ue *models.UeProfile) (string, error) {
+	var opc string
+	var err error
+	switch ue.OpType {
+	case utils.OPC, utils.TOPC:
+		return ue.Op, nil
+	case utils.OP:
+		opc, err = milenage.GenerateOpcHex(ue.Key, ue.Op)
+	case utils.TOP:
+		opc, err = tuak.GenerateTopcHex(ue.Key, ue.Op)
+	default:
+		return "", fmt.Errorf("unknown OP type %q for SUPI %s", ue.OpType, ue.Supi)
+	}
+	if err != nil {
+		log.Printf("Error deriving OPc for SUPI %s: %v", ue.Supi, err)
+		return "", err
+	}
+	return opc, nil
+}
+
+// UpdateUeProfile validates a UE Profile and updates the stored one with its SUPI
 func (s *UeProfileService) UpdateUeProfile(supi string, ue *models.UeProfile) error {
+	ue.Supi = supi
+	if err := checkUeProfile(ue); err != nil {
+		return err
+	}
+	return s.updateUeProfile(supi, ue)
+}
+
+// updateUeProfile writes a UE Profile without validating it, so internal maintenance such as key
+// rotation still works on profiles stored before UE Profiles were validated
+func (s *UeProfileService) updateUeProfile(supi string, ue *models.UeProfile) error {
 	// Ensure that supi is not overwritten
 	ue.Supi = supi
+	sealed, err := s.sealSecrets(ue)
+	if err != nil {
+		return err
+	}
 
 	update := bson.M{
-		"$set": ue,
+		"$set": sealed,
 	}
 
 	result, err := s.collection.UpdateOne(context.Background(), bson.M{"supi": supi}, update)
@@ -114,3 +334,89 @@ func (s *UeProfileService) DeleteUeProfile(supi string) error {
 
 	return nil
 }
+
+// FindMalformedSucis parses the SUCI of every stored UE Profile and reports the ones that are malformed
+func (s *UeProfileService) FindMalformedSucis() ([]MalformedSuci, error) {
+	profiles, err := s.GetAllUEProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var malformed []MalformedSuci
+	for _, profile := range profiles {
+		if _, err := supi.ParseSuci(profile.Suci); err != nil {
+			log.Printf("Malformed SUCI for SUPI %s: %v", profile.Supi, err)
+			malformed = append(malformed, MalformedSuci{
+				Supi:  profile.Supi,
+				Suci:  profile.Suci,
+				Error: err.Error(),
+			})
+		}
+	}
+	return malformed, nil
+}
+
+// RepairSucis recomputes the SUCI of every stored UE Profile from its SUPI and home network key.
+// It returns the new SUCI of each repaired profile keyed by SUPI.
+func (s *UeProfileService) RepairSucis() (map[string]string, error) {
+	profiles, err := s.GetAllUEProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	repaired := make(map[string]string)
+	for i := range profiles {
+		profile := &profiles[i]
+		suci, err := utils.RecomputeSuci(profile)
+		if err != nil {
+			log.Printf("Error recomputing SUCI for SUPI %s: %v", profile.Supi, err)
+			return repaired, err
+		}
+
+		update := bson.M{
+			"$set": bson.M{"suci": suci},
+		}
+		if _, err := s.collection.UpdateOne(context.Background(), bson.M{"supi": profile.Supi}, update); err != nil {
+			log.Printf("Error updating SUCI for SUPI %s: %v", profile.Supi, err)
+			return repaired, err
+		}
+		repaired[profile.Supi] = suci
+	}
+	return repaired, nil
+}
+
+// RepairSds rewrites the slice differentiators of stored UE Profiles in their stored form, 6 lowercase
+// hex digits, replacing SDs such as "0x010203". It returns the SUPIs of the profiles it changed.
+func (s *UeProfileService) RepairSds() ([]string, error) {
+	var profiles []models.UeProfile
+	projection := bson.M{"supi": 1, "configuredslice": 1, "defaultslice": 1, "sessions": 1}
+	if err := findAll(s.collection, projection, &profiles); err != nil {
+		return nil, err
+	}
+
+	var repaired []string
+	for _, profile := range profiles {
+		normalized := profile
+		if err := utils.NormalizeUeSds(&normalized); err != nil {
+			log.Printf("Skipping SUPI %s: %v", profile.Supi, err)
+			continue
+		}
+		if reflect.DeepEqual(normalized, profile) {
+			continue
+		}
+
+		update := bson.M{
+			"$set": bson.M{
+				"configuredslice": normalized.UeConfiguredNssai,
+				"defaultslice":    normalized.UeDefaultNssai,
+				"sessions":        normalized.Sessions,
+			},
+		}
+		if _, err := s.collection.UpdateOne(context.Background(), bson.M{"supi": profile.Supi}, update); err != nil {
+			log.Printf("Error updating SDs for SUPI %s: %v", profile.Supi, err)
+			return repaired, err
+		}
+		repaired = append(repaired, profile.Supi)
+	}
+	return repaired, nil
+}
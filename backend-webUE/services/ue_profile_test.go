@@ -0,0 +1,43 @@
+package services
+
+import (
+	"backend-webUE/secrets"
+	"backend-webUE/utils"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master.key")
+	if _, err := secrets.AddMasterKey(path); err != nil {
+		t.Fatalf("AddMasterKey failed: %v", err)
+	}
+	t.Setenv(secrets.MasterKeyFileEnv, path)
+	if keyring := envKeyring(); keyring.ActiveVersion() != 1 {
+		t.Fatalf("envKeyring active version = %d, want 1", keyring.ActiveVersion())
+	}
+
+	t.Setenv(secrets.MasterKeyFileEnv, "")
+	if keyring := envKeyring(); keyring != nil {
+		t.Fatal("envKeyring returned a keyring without a master key file")
+	}
+}
+
+func TestGenerateUEProfilesSeedNeedsTestMode(t *testing.T) {
+	s := &UeProfileService{}
+	seed := int64(18)
+	config := &utils.OperatorConfig{Seed: &seed}
+
+	t.Setenv(utils.TestModeEnv, "")
+	var requestErr *RequestError
+	if _, _, err := s.GenerateUEProfiles(config, 0); !errors.As(err, &requestErr) || !errors.Is(err, utils.ErrSeedOutsideTestMode) {
+		t.Fatalf("GenerateUEProfiles with a seed outside test mode = %v, want a RequestError", err)
+	}
+
+	// In test mode the seed is accepted and the batch size is checked next
+	t.Setenv(utils.TestModeEnv, "true")
+	if _, _, err := s.GenerateUEProfiles(config, 0); err == nil || errors.Is(err, utils.ErrSeedOutsideTestMode) {
+		t.Fatalf("GenerateUEProfiles with a seed in test mode = %v, want the batch size rejected", err)
+	}
+}
@@ -0,0 +1,314 @@
+// services/ue_template.go
+package services
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/utils"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UeTemplateService stores named generation templates and generates UE Profiles from them
+type UeTemplateService struct {
+	collection       *mongo.Collection
+	ueProfileService *UeProfileService
+	// keySource supplies the active home network key of each scheme of a template's key profile
+	keySource utils.HomeNetworkKeySource
+}
+
+// NewUeTemplateService creates a new UeTemplateService
+func NewUeTemplateService(db *mongo.Database, ueProfileService *UeProfileService, keySource utils.HomeNetworkKeySource) *UeTemplateService {
+	collection := db.Collection("ue_templates")
+
+	// Templates are shared by name, so names must stay unique
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Error creating UE template name index: %v", err)
+	}
+
+	return &UeTemplateService{
+		collection:       collection,
+		ueProfileService: ueProfileService,
+		keySource:        keySource,
+	}
+}
+
+// CreateTemplate validates and stores a new template
+func (s *UeTemplateService) CreateTemplate(template *models.UeTemplate) error {
+	if err := ValidateUeTemplate(template); err != nil {
+		return invalidRequest(err)
+	}
+	normalizeTemplateSds(template)
+	template.ID = primitive.NilObjectID
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+
+	result, err := s.collection.InsertOne(context.Background(), template)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return invalidRequest(fmt.Errorf("UE template %q already exists", template.Name))
+		}
+		log.Printf("Error inserting UE template: %v", err)
+		return err
+	}
+	template.ID, _ = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetAllTemplates retrieves all templates sorted by name
+func (s *UeTemplateService) GetAllTemplates() ([]models.UeTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := s.collection.Find(context.Background(), bson.M{}, opts)
+	if err != nil {
+		log.Printf("Error fetching UE templates: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var templates []models.UeTemplate
+	if err := cursor.All(context.Background(), &templates); err != nil {
+		log.Printf("Error decoding UE templates: %v", err)
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetTemplate retrieves a template by its ID
+func (s *UeTemplateService) GetTemplate(id string) (*models.UeTemplate, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidRequest(fmt.Errorf("invalid UE template ID %q", id))
+	}
+	var template models.UeTemplate
+	err = s.collection.FindOne(context.Background(), bson.M{"_id": objectId}).Decode(&template)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error fetching UE template %s: %v", id, err)
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// UpdateTemplate replaces the definition of a template, keeping its ID and creation time
+func (s *UeTemplateService) UpdateTemplate(id string, template *models.UeTemplate) error {
+	if err := ValidateUeTemplate(template); err != nil {
+		return invalidRequest(err)
+	}
+	normalizeTemplateSds(template)
+	stored, err := s.GetTemplate(id)
+	if err != nil {
+		return err
+	}
+	template.ID = stored.ID
+	template.CreatedAt = stored.CreatedAt
+	template.UpdatedAt = time.Now()
+
+	result, err := s.collection.ReplaceOne(context.Background(), bson.M{"_id": stored.ID}, template)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return invalidRequest(fmt.Errorf("UE template %q already exists", template.Name))
+		}
+		log.Printf("Error updating UE template %s: %v", id, err)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteTemplate deletes a template. UE Profiles generated from it are kept.
+func (s *UeTemplateService) DeleteTemplate(id string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return invalidRequest(fmt.Errorf("invalid UE template ID %q", id))
+	}
+	result, err := s.collection.DeleteOne(context.Background(), bson.M{"_id": objectId})
+	if err != nil {
+		log.Printf("Error deleting UE template %s: %v", id, err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// GenerateFromTemplate generates and inserts UE Profiles from a stored template with the
+// request's overrides applied. It returns the profiles and the SUPIs skipped as already in use.
+func (s *UeTemplateService) GenerateFromTemplate(req models.TemplateGenerateRequest) ([]models.UeProfile, []string, error) {
+	if req.NumUes < 1 {
+		return nil, nil, invalidRequest(fmt.Errorf("number of UE Profiles must be at least 1, got %d", req.NumUes))
+	}
+	stored, err := s.GetTemplate(req.TemplateId)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := ApplyTemplateOverrides(*stored, req.Overrides)
+	if err := ValidateUeTemplate(&template); err != nil {
+		return nil, nil, invalidRequest(err)
+	}
+
+	config := TemplateOperatorConfig(&template)
+	config.KeySource = s.keySource
+	config.Seed = req.Seed
+	config.SupiAllocation = req.SupiAllocation
+	config.MsinStart = req.MsinStart
+	config.MsinEnd = req.MsinEnd
+	return s.ueProfileService.GenerateUEProfiles(config, req.NumUes)
+}
+
+// ApplyTemplateOverrides returns a copy of the template with the fields set in overrides replaced
+func ApplyTemplateOverrides(template models.UeTemplate, overrides models.UeTemplateOverrides) models.UeTemplate {
+	if overrides.PlmnId != nil {
+		template.PlmnId = *overrides.PlmnId
+	}
+	if overrides.UeConfiguredNssai != nil {
+		template.UeConfiguredNssai = overrides.UeConfiguredNssai
+	}
+	if overrides.UeDefaultNssai != nil {
+		template.UeDefaultNssai = overrides.UeDefaultNssai
+	}
+	if overrides.Sessions != nil {
+		template.Sessions = overrides.Sessions
+	}
+	if overrides.Integrity != nil {
+		template.Integrity = *overrides.Integrity
+	}
+	if overrides.Ciphering != nil {
+		template.Ciphering = *overrides.Ciphering
+	}
+	if overrides.IntegrityMaxRate != nil {
+		template.IntegrityMaxRate = *overrides.IntegrityMaxRate
+	}
+	if overrides.UacAic != nil {
+		template.UacAic = *overrides.UacAic
+	}
+	if overrides.UacAcc != nil {
+		template.UacAcc = *overrides.UacAcc
+	}
+	if overrides.ProtectionSchemes != nil {
+		template.ProtectionSchemes = overrides.ProtectionSchemes
+	}
+	if overrides.AuthAlgorithm != nil {
+		template.AuthAlgorithm = *overrides.AuthAlgorithm
+	}
+	if overrides.Amf != nil {
+		template.Amf = *overrides.Amf
+	}
+	if overrides.GnbSearchList != nil {
+		template.GnbSearchList = overrides.GnbSearchList
+	}
+	if overrides.Tacs != nil {
+		template.Tacs = overrides.Tacs
+	}
+	if overrides.Svn != nil {
+		template.Svn = *overrides.Svn
+	}
+	if overrides.Distribution != nil {
+		template.Distribution = overrides.Distribution
+	}
+	return template
+}
+
+// TemplateOperatorConfig returns the operator configuration of a template. The key profile
+// only selects schemes: the caller sets the KeySource that supplies their keys.
+func TemplateOperatorConfig(template *models.UeTemplate) *utils.OperatorConfig {
+	config := &utils.OperatorConfig{
+		PlmnId:            template.PlmnId,
+		Amf:               template.Amf,
+		UeConfiguredNssai: template.UeConfiguredNssai,
+		UeDefaultNssai:    template.UeDefaultNssai,
+		GnbSearchList:     template.GnbSearchList,
+		Sessions:          template.Sessions,
+		UacAic:            template.UacAic,
+		UacAcc:            template.UacAcc,
+		Integrity:         template.Integrity,
+		Ciphering:         template.Ciphering,
+		IntegrityMaxRate:  template.IntegrityMaxRate,
+		Tacs:              template.Tacs,
+		Svn:               template.Svn,
+		AuthAlgorithm:     template.AuthAlgorithm,
+		Distribution:      template.Distribution,
+	}
+	if config.Amf == "" {
+		config.Amf = utils.DEFAULT_AMF
+	}
+	for _, scheme := range template.ProtectionSchemes {
+		config.Profiles = append(config.Profiles, models.Profile{Scheme: scheme})
+	}
+	return config
+}
+
+// templateUe returns a UE Profile with the slices and sessions of a template
+func templateUe(template *models.UeTemplate) *models.UeProfile {
+	return &models.UeProfile{
+		UeConfiguredNssai: template.UeConfiguredNssai,
+		UeDefaultNssai:    template.UeDefaultNssai,
+		Sessions:          template.Sessions,
+	}
+}
+
+// normalizeTemplateSds stores the SDs of a validated template in their stored form
+func normalizeTemplateSds(template *models.UeTemplate) {
+	ue := templateUe(template)
+	if err := utils.NormalizeUeSds(ue); err != nil {
+		return
+	}
+	template.UeConfiguredNssai = ue.UeConfiguredNssai
+	template.UeDefaultNssai = ue.UeDefaultNssai
+	template.Sessions = ue.Sessions
+}
+
+// ValidateUeTemplate checks the name, PLMN, sessions, key profile, algorithm, gNB list, IMEI settings
+// and batch distribution of a template
+func ValidateUeTemplate(template *models.UeTemplate) error {
+	if template.Name == "" {
+		return fmt.Errorf("UE template name is required")
+	}
+	if _, err := utils.PlmnOf(template.PlmnId); err != nil {
+		return err
+	}
+	// Sessions are checked as the UEs generated from the template carry them
+	if err := utils.NormalizeUeSessions(templateUe(template)); err != nil {
+		return err
+	}
+	for _, scheme := range template.ProtectionSchemes {
+		switch scheme {
+		case utils.NULL_SCHEME, utils.A_SCHEME, utils.B_SCHEME:
+		default:
+			return fmt.Errorf("unsupported profile scheme: %d", scheme)
+		}
+	}
+	if err := utils.ValidateAuthAlgorithm(template.AuthAlgorithm); err != nil {
+		return err
+	}
+	for _, address := range template.GnbSearchList {
+		if net.ParseIP(address) == nil {
+			return fmt.Errorf("gNB address %q is not an IP address", address)
+		}
+	}
+	for _, tac := range template.Tacs {
+		if err := utils.ValidateTac(tac); err != nil {
+			return err
+		}
+	}
+	if template.Svn != "" {
+		if err := utils.ValidateSvn(template.Svn); err != nil {
+			return err
+		}
+	}
+	return utils.ValidateDistribution(TemplateOperatorConfig(template))
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/utils"
+	"errors"
+	"testing"
+)
+
+func testTemplate() models.UeTemplate {
+	return models.UeTemplate{
+		Name:              "lab-208-93",
+		PlmnId:            models.PlmnId{Mcc: "208", Mnc: "93"},
+		UeConfiguredNssai: []models.Snssai{{Sst: 1, Sd: "010203"}},
+		UeDefaultNssai:    []models.Snssai{{Sst: 1, Sd: "010203"}},
+		Integrity:         models.Integrity{IA1: true, IA2: true},
+		ProtectionSchemes: []int{utils.NULL_SCHEME},
+		GnbSearchList:     []string{"127.0.0.1"},
+		Tacs:              []string{"35145120"},
+	}
+}
+
+func TestApplyTemplateOverrides(t *testing.T) {
+	template := testTemplate()
+	plmnId := models.PlmnId{Mcc: "310", Mnc: "410"}
+	integrity := models.Integrity{}
+	applied := ApplyTemplateOverrides(template, models.UeTemplateOverrides{
+		PlmnId:        &plmnId,
+		Integrity:     &integrity,
+		GnbSearchList: []string{"10.0.0.1", "10.0.0.2"},
+	})
+
+	if applied.PlmnId != plmnId || applied.Integrity != integrity || len(applied.GnbSearchList) != 2 {
+		t.Fatalf("overrides not applied: %+v", applied)
+	}
+	if applied.Name != template.Name || len(applied.Tacs) != 1 || applied.UeConfiguredNssai[0].Sst != 1 {
+		t.Fatalf("fields without override changed: %+v", applied)
+	}
+	if template.PlmnId.Mcc != "208" || len(template.GnbSearchList) != 1 {
+		t.Fatalf("template modified: %+v", template)
+	}
+}
+
+func TestTemplateOperatorConfig(t *testing.T) {
+	template := testTemplate()
+	config := TemplateOperatorConfig(&template)
+	if config.Amf != utils.DEFAULT_AMF || len(config.Profiles) != 1 || config.Profiles[0].Scheme != utils.NULL_SCHEME {
+		t.Fatalf("TemplateOperatorConfig = %+v", config)
+	}
+
+	// Two batches generated from the same template and seed are the same fleet
+	t.Setenv(utils.TestModeEnv, "true")
+	seed := int64(22)
+	var fleets [2][]models.UeProfile
+	for i := range fleets {
+		config := TemplateOperatorConfig(&template)
+		config.Seed = &seed
+		var err error
+		if fleets[i], err = utils.NewOperator(config).GenerateUes(2); err != nil {
+			t.Fatalf("GenerateUes failed: %v", err)
+		}
+	}
+	ue := fleets[0][1]
+	if ue.Imei[:utils.TacLen] != "35145120" || ue.GnbSearchList[0] != "127.0.0.1" || ue.PlmnId != template.PlmnId {
+		t.Fatalf("UE Profile does not follow the template: %+v", ue)
+	}
+	if fleets[1][1].Supi != ue.Supi || fleets[1][1].Key != ue.Key {
+		t.Fatal("the same template and seed generated different UE Profiles")
+	}
+}
+
+func TestValidateUeTemplate(t *testing.T) {
+	valid := testTemplate()
+	if err := ValidateUeTemplate(&valid); err != nil {
+		t.Fatalf("ValidateUeTemplate rejected a valid template: %v", err)
+	}
+	valid.Distribution = &models.BatchDistribution{
+		OpTypes: []models.OpTypeShare{{OpType: utils.OP, Percent: 30}, {OpType: utils.OPC, Percent: 70}},
+	}
+	if err := ValidateUeTemplate(&valid); err != nil {
+		t.Fatalf("ValidateUeTemplate rejected a template with a distribution: %v", err)
+	}
+
+	for name, change := range map[string]func(*models.UeTemplate){
+		"no name":        func(tp *models.UeTemplate) { tp.Name = "" },
+		"no PLMN":        func(tp *models.UeTemplate) { tp.PlmnId = models.PlmnId{} },
+		"4-digit MNC":    func(tp *models.UeTemplate) { tp.PlmnId.Mnc = "9300" },
+		"unknown scheme": func(tp *models.UeTemplate) { tp.ProtectionSchemes = []int{3} },
+		"unknown alg":    func(tp *models.UeTemplate) { tp.AuthAlgorithm = "COMP128" },
+		"short TAC":      func(tp *models.UeTemplate) { tp.Tacs = []string{"3514512"} },
+		"reserved SVN":   func(tp *models.UeTemplate) { tp.Svn = "99" },
+		"gNB hostname":   func(tp *models.UeTemplate) { tp.GnbSearchList = []string{"gnb.local"} },
+		"90% schemes": func(tp *models.UeTemplate) {
+			tp.Distribution = &models.BatchDistribution{Schemes: []models.SchemeShare{{Scheme: utils.NULL_SCHEME, Percent: 90}}}
+		},
+		"scheme outside the key profile": func(tp *models.UeTemplate) {
+			tp.Distribution = &models.BatchDistribution{Schemes: []models.SchemeShare{{Scheme: utils.A_SCHEME, Percent: 100}}}
+		},
+		"TUAK OP type of Milenage": func(tp *models.UeTemplate) {
+			tp.Distribution = &models.BatchDistribution{OpTypes: []models.OpTypeShare{{OpType: utils.TOPC, Percent: 100}}}
+		},
+	} {
+		template := testTemplate()
+		change(&template)
+		if err := ValidateUeTemplate(&template); err == nil {
+			t.Errorf("ValidateUeTemplate accepted a template with %s", name)
+		}
+	}
+}
+
+func TestTemplateRequestErrors(t *testing.T) {
+	s := &UeTemplateService{}
+	var requestErr *RequestError
+	if err := s.CreateTemplate(&models.UeTemplate{}); !errors.As(err, &requestErr) {
+		t.Errorf("CreateTemplate of an invalid template = %v, want a RequestError", err)
+	}
+	if _, err := s.GetTemplate("lab"); !errors.As(err, &requestErr) {
+		t.Errorf("GetTemplate of a malformed ID = %v, want a RequestError", err)
+	}
+	if err := s.DeleteTemplate("lab"); !errors.As(err, &requestErr) {
+		t.Errorf("DeleteTemplate of a malformed ID = %v, want a RequestError", err)
+	}
+	if _, _, err := s.GenerateFromTemplate(models.TemplateGenerateRequest{TemplateId: "65f000000000000000000001"}); !errors.As(err, &requestErr) {
+		t.Errorf("GenerateFromTemplate of 0 UE Profiles = %v, want a RequestError", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 
@@ -36,6 +37,7 @@ const (
 	ProfileAIcbLen    = 16 // octets
 	ProfileAMacLen    = 8  // octets
 	ProfileAHashLen   = 32 // octets
+	ProfileAPubKeyLen = 32 // octets
 )
 
 // profile B.
@@ -45,6 +47,7 @@ const (
 	ProfileBIcbLen    = 16 // octets
 	ProfileBMacLen    = 8  // octets
 	ProfileBHashLen   = 32 // octets
+	ProfileBPubKeyLen = 33 // octets, compressed point
 )
 
 type X25519 struct {
@@ -74,7 +77,13 @@ type PrivateKey struct {
 
 func (x *X25519) GenerateKeyFromExistingPrivateKey(ExistingPrivateKey string) error {
 
-	existingPrivateKey, _ := hex.DecodeString(ExistingPrivateKey)
+	existingPrivateKey, err := hex.DecodeString(ExistingPrivateKey)
+	if err != nil {
+		return fmt.Errorf("invalid X25519 private key: %v", err)
+	}
+	if len(existingPrivateKey) != PrivateKeySize {
+		return fmt.Errorf("invalid X25519 private key length: %d octets", len(existingPrivateKey))
+	}
 	x.privKey = existingPrivateKey
 	privateKey := new(PrivateKey)
 	copy(privateKey.b[:], existingPrivateKey[:])
@@ -120,6 +129,9 @@ func (x *X25519) GetPrivKey() []byte {
 }
 
 func (x *X25519) GenerateSharedKey(hnPubKey []byte) ([]byte, error) {
+	if len(hnPubKey) != curve25519.PointSize {
+		return nil, fmt.Errorf("invalid X25519 public key length: %d octets", len(hnPubKey))
+	}
 	sharedKeyTmp, err := curve25519.X25519(x.privKey, hnPubKey)
 	if err != nil {
 		log.Errorf("X25519 error: %+v", err)
@@ -166,8 +178,17 @@ func (x *Secp256r1) GenerateKeyFromExistingPrivateKey(hexPrivateKey string) erro
 	/*
 		Generate a key pair with a pre-determined private key.
 	*/
-	bytePrivKey, _ := hex.DecodeString(hexPrivateKey)
+	bytePrivKey, err := hex.DecodeString(hexPrivateKey)
+	if err != nil {
+		return fmt.Errorf("invalid secp256r1 private key: %v", err)
+	}
+	if len(bytePrivKey) != 32 {
+		return fmt.Errorf("invalid secp256r1 private key length: %d octets", len(bytePrivKey))
+	}
 	privateKey := new(big.Int).SetBytes(bytePrivKey)
+	if privateKey.Sign() == 0 || privateKey.Cmp(elliptic.P256().Params().N) >= 0 {
+		return fmt.Errorf("secp256r1 private key out of range")
+	}
 	x.privKey = new(ecdsa.PrivateKey)
 	x.privKey.PublicKey.Curve = elliptic.P256()
 	x.privKey.D = new(big.Int).Set(privateKey)
@@ -183,7 +204,7 @@ func (x *Secp256r1) GetPubKey() []byte {
 }
 
 func (x *Secp256r1) GetPrivKey() []byte {
-	return x.privKey.D.Bytes()
+	return x.privKey.D.FillBytes(make([]byte, 32))
 }
 
 func compressPublicKey(pubkey *ecdsa.PublicKey) []byte {
@@ -256,12 +277,17 @@ func (x *Secp256r1) GenerateSharedKey(bytehnPubKey []byte) ([]byte, error) {
 	/*
 	   generate_sharedkey - get the shared key
 	*/
-	hnPubKey, _ := DecompressPubkey(bytehnPubKey)
+	hnPubKey, err := DecompressPubkey(bytehnPubKey)
+	if err != nil {
+		return []byte{}, err
+	}
 	if err := checkOnCurve(elliptic.P256(), hnPubKey.X, hnPubKey.Y); err != nil {
 		return []byte{}, err
 	}
 	decryptSharedKeytmp, _ := elliptic.P256().ScalarMult(hnPubKey.X, hnPubKey.Y, x.privKey.D.Bytes())
-	decryptSharedKey := decryptSharedKeytmp.Bytes()
+	// The shared secret is the x-coordinate as a fixed 32-octet string (SEC 1, 3.3.1),
+	// so leading zero octets must be kept.
+	decryptSharedKey := decryptSharedKeytmp.FillBytes(make([]byte, 32))
 	return decryptSharedKey, nil
 }
 
@@ -272,7 +298,10 @@ func KDF(sharedKey, publicKey []byte, profileEncKeyLen, profileMacKeyLen, profil
 	for i := 1; i <= kdfRounds; i++ {
 		counterBytes := make([]byte, 4)
 		binary.BigEndian.PutUint32(counterBytes, counter)
-		tmpK := sha256.Sum256(append(append(sharedKey, counterBytes...), publicKey...))
+		// Build a fresh buffer so sharedKey's backing array is never written to.
+		input := make([]byte, 0, len(sharedKey)+len(counterBytes)+len(publicKey))
+		input = append(append(append(input, sharedKey...), counterBytes...), publicKey...)
+		tmpK := sha256.Sum256(input)
 		kdfKey = append(kdfKey, tmpK[:]...)
 		counter++
 	}
@@ -316,6 +345,50 @@ func protect(supi []byte, kdfKey []byte) ([]byte, []byte) {
 	return cipherText, encryptMacTag
 }
 
+// EncodeMsinBcd encodes the MSIN digits as the scheme input of TS 24.501 9.11.3.4:
+// BCD with swapped nibbles and a 0xF filler in the last octet for an odd number of digits.
+func EncodeMsinBcd(msin string) ([]byte, error) {
+	if len(msin) == 0 {
+		return nil, fmt.Errorf("empty MSIN")
+	}
+	bcd := make([]byte, (len(msin)+1)/2)
+	for i := 0; i < len(msin); i++ {
+		c := msin[i]
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("invalid MSIN digit %q in %s", c, msin)
+		}
+		if i%2 == 0 {
+			bcd[i/2] = c - '0'
+		} else {
+			bcd[i/2] |= (c - '0') << 4
+		}
+	}
+	if len(msin)%2 == 1 {
+		bcd[len(bcd)-1] |= 0xf0
+	}
+	return bcd, nil
+}
+
+// DecodeMsinBcd is the inverse of EncodeMsinBcd.
+func DecodeMsinBcd(bcd []byte) (string, error) {
+	digits := make([]byte, 0, len(bcd)*2)
+	for i, b := range bcd {
+		low, high := b&0x0f, b>>4
+		if low > 9 {
+			return "", fmt.Errorf("invalid BCD octet %02x", b)
+		}
+		digits = append(digits, '0'+low)
+		if high == 0x0f && i == len(bcd)-1 {
+			break
+		}
+		if high > 9 {
+			return "", fmt.Errorf("invalid BCD octet %02x", b)
+		}
+		digits = append(digits, '0'+high)
+	}
+	return string(digits), nil
+}
+
 type EllipticCurve interface {
 	GetPubKey() []byte
 	GenerateKeyPair() error
@@ -324,41 +397,84 @@ type EllipticCurve interface {
 }
 
 // Factory functions to create instances
-func NewX25519(loc_privKey string) EllipticCurve {
+func NewX25519(loc_privKey string) (EllipticCurve, error) {
 	x := &X25519{}
+	var err error
 	if loc_privKey == "" {
-		x.GenerateKeyPair()
+		err = x.GenerateKeyPair()
 	} else {
-		x.GenerateKeyFromExistingPrivateKey(loc_privKey)
+		err = x.GenerateKeyFromExistingPrivateKey(loc_privKey)
 	}
-	return x
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-func NewSecp256r1(loc_privKey string) EllipticCurve {
+func NewSecp256r1(loc_privKey string) (EllipticCurve, error) {
 	x := &Secp256r1{}
+	var err error
 	if loc_privKey == "" {
-		x.GenerateKeyPair()
+		err = x.GenerateKeyPair()
 	} else {
-		x.GenerateKeyFromExistingPrivateKey(loc_privKey)
+		err = x.GenerateKeyFromExistingPrivateKey(loc_privKey)
 	}
-	return x
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-func encode_supi(profile string, stringHnPubKey string, ephprivKey string, msinString string) (string, error) {
-	var a EllipticCurve
-	if profile == "A" {
-		a = NewX25519(ephprivKey)
-	} else {
-		a = NewSecp256r1(ephprivKey)
+// NewEphemeralKey creates a fresh ephemeral key pair for the given profile:
+// X25519 for Profile A and secp256r1 for Profile B.
+func NewEphemeralKey(profile string) (EllipticCurve, error) {
+	return NewEphemeralKeyFrom(profile, rand.Reader)
+}
+
+// NewEphemeralKeyFrom creates an ephemeral key pair for the given profile with a private key
+// read from the entropy source r
+func NewEphemeralKeyFrom(profile string, r io.Reader) (EllipticCurve, error) {
+	privKey := make([]byte, PrivateKeySize)
+	switch profile {
+	case "A":
+		if _, err := io.ReadFull(r, privKey); err != nil {
+			return nil, fmt.Errorf("failed to read entropy: %v", err)
+		}
+		return NewX25519(hex.EncodeToString(privKey))
+	case "B":
+		// Sample until the scalar is in [1, N-1] so every private key is equally likely
+		n := elliptic.P256().Params().N
+		for {
+			if _, err := io.ReadFull(r, privKey); err != nil {
+				return nil, fmt.Errorf("failed to read entropy: %v", err)
+			}
+			d := new(big.Int).SetBytes(privKey)
+			if d.Sign() > 0 && d.Cmp(n) < 0 {
+				return NewSecp256r1(hex.EncodeToString(privKey))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported profile: %s", profile)
 	}
+}
 
-	hnPubKey, err := hex.DecodeString(stringHnPubKey)
+func encode_supi(profile string, stringHnPubKey string, ephprivKey string, schemeInput []byte) (string, error) {
+	var a EllipticCurve
+	var err error
+	switch profile {
+	case "A":
+		a, err = NewX25519(ephprivKey)
+	case "B":
+		a, err = NewSecp256r1(ephprivKey)
+	default:
+		return "", fmt.Errorf("unsupported profile: %s", profile)
+	}
 	if err != nil {
-		log.Errorf("DecodeString error: %+v", err)
+		log.Errorf("Ephemeral key error: %+v", err)
 		return "", err
 	}
 
-	msin, err := hex.DecodeString(msinString)
+	hnPubKey, err := hex.DecodeString(stringHnPubKey)
 	if err != nil {
 		log.Errorf("DecodeString error: %+v", err)
 		return "", err
@@ -373,7 +489,7 @@ func encode_supi(profile string, stringHnPubKey string, ephprivKey string, msinS
 	}
 
 	kdf_key := KDF(sharedKey, a.GetPubKey(), ProfileAEncKeyLen, ProfileAMacKeyLen, ProfileAHashLen)
-	suci_bytes, macTag_UE_bytes := protect(msin, kdf_key)
+	suci_bytes, macTag_UE_bytes := protect(schemeInput, kdf_key)
 
 	suci := hex.EncodeToString(suci_bytes)
 	macTag_UE := hex.EncodeToString(macTag_UE_bytes)
@@ -382,5 +498,97 @@ func encode_supi(profile string, stringHnPubKey string, ephprivKey string, msinS
 }
 
 func Supi2Suci(profile string, stringHnPubKey string, ephprivKey string, msinString string) (string, error) {
-	return encode_supi(profile, stringHnPubKey, ephprivKey, msinString)
+	msin, err := EncodeMsinBcd(msinString)
+	if err != nil {
+		log.Errorf("EncodeMsinBcd error: %+v", err)
+		return "", err
+	}
+	return encode_supi(profile, stringHnPubKey, ephprivKey, msin)
+}
+
+// Nai2Suci conceals the username part of a NAI-type SUPI. The scheme input is the
+// username as UTF-8 octets (TS 24.501 9.11.3.4).
+func Nai2Suci(profile string, stringHnPubKey string, ephprivKey string, username string) (string, error) {
+	if username == "" {
+		return "", fmt.Errorf("empty NAI username")
+	}
+	return encode_supi(profile, stringHnPubKey, ephprivKey, []byte(username))
+}
+
+func decode_suci(profile string, stringHnPrivKey string, ephPubKey, cipherText, macTag []byte) ([]byte, error) {
+	var a EllipticCurve
+	var err error
+	switch profile {
+	case "A":
+		a, err = NewX25519(stringHnPrivKey)
+	case "B":
+		a, err = NewSecp256r1(stringHnPrivKey)
+	default:
+		return nil, fmt.Errorf("unsupported profile: %s", profile)
+	}
+	if err != nil {
+		log.Errorf("Home network key error: %+v", err)
+		return nil, err
+	}
+
+	sharedKey, err := a.GenerateSharedKey(ephPubKey)
+	if err != nil {
+		log.Errorf("GenerateSharedKey error: %+v", err)
+		return nil, err
+	}
+
+	kdf_key := KDF(sharedKey, ephPubKey, ProfileAEncKeyLen, ProfileAMacKeyLen, ProfileAHashLen)
+	decryptEncKey := kdf_key[:16]
+	decryptIcb := kdf_key[16:32]
+	macKey := kdf_key[32:64]
+
+	decryptMacTag, err := HmacSha256(cipherText, macKey, ProfileAMacLen)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(decryptMacTag, macTag) {
+		return nil, fmt.Errorf("MAC tag verification failed")
+	}
+
+	return Aes128ctr(cipherText, decryptEncKey, decryptIcb), nil
+}
+
+// Suci2Supi de-conceals a SUCI of the form
+// suci-0-<mcc>-<mnc>-<routingIndicator>-<scheme>-<keyId>-<schemeOutput> or
+// suci-1-<realm>-<routingIndicator>-<scheme>-<keyId>-<schemeOutput>
+// with the home network private key and returns the IMSI or NAI SUPI.
+func Suci2Supi(suciString string, stringHnPrivKey string) (string, error) {
+	suci, err := ParseSuci(suciString)
+	if err != nil {
+		return "", err
+	}
+
+	var schemeInput []byte
+	switch suci.ProtectionSchemeId {
+	case NullSchemeId:
+		if suci.SupiType == SupiTypeNai {
+			return "nai-" + suci.Username + "@" + suci.HomeNetworkIdentifier, nil
+		}
+		return "imsi-" + suci.Mcc + suci.Mnc + suci.Msin, nil
+	case ProfileASchemeId:
+		schemeInput, err = decode_suci("A", stringHnPrivKey, suci.EphemeralPublicKey, suci.CipherText, suci.MacTag)
+	case ProfileBSchemeId:
+		schemeInput, err = decode_suci("B", stringHnPrivKey, suci.EphemeralPublicKey, suci.CipherText, suci.MacTag)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if suci.SupiType == SupiTypeNai {
+		username := string(schemeInput)
+		if !isNaiPart(username) {
+			return "", fmt.Errorf("invalid NAI username in scheme output")
+		}
+		return "nai-" + username + "@" + suci.HomeNetworkIdentifier, nil
+	}
+	msin, err := DecodeMsinBcd(schemeInput)
+	if err != nil {
+		return "", err
+	}
+	return "imsi-" + suci.Mcc + suci.Mnc + msin, nil
 }
@@ -0,0 +1,318 @@
+package supi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Test sets from 3GPP TS 33.501 Annex C.4.
+type eciesTestSet struct {
+	name       string
+	profile    string
+	hnPrivKey  string
+	hnPubKey   string
+	ephPrivKey string
+	ephPubKey  string
+	sharedKey  string
+	msin       string
+	plainText  string
+	cipherText string
+	macTag     string
+	encKeyLen  int
+	macKeyLen  int
+	hashLen    int
+}
+
+var eciesTestSets = []eciesTestSet{
+	{
+		name:       "C.4.3 Profile A",
+		profile:    "A",
+		hnPrivKey:  "c53c22208b61860b06c62e5406a7b330c2b577aa5558981510d128247d38bd1d",
+		hnPubKey:   "5a8d38864820197c3394b92613b20b91633cbd897119273bf8e4a6f4eec0a650",
+		ephPrivKey: "c80949f13ebe61af4ebdbd293ea4f942696b9e815d7e8f0096bbf6ed7de62256",
+		ephPubKey:  "b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457d",
+		sharedKey:  "028ddf890ec83cdf163947ce45f6ec1a0e3070ea5fe57e2b1f05139f3e82422a",
+		msin:       "001002086",
+		plainText:  "00012080f6",
+		cipherText: "cb02352410",
+		macTag:     "cddd9e730ef3fa87",
+		encKeyLen:  ProfileAEncKeyLen,
+		macKeyLen:  ProfileAMacKeyLen,
+		hashLen:    ProfileAHashLen,
+	},
+	{
+		name:       "C.4.4 Profile B",
+		profile:    "B",
+		hnPrivKey:  "f1ab1074477ebcc7f554ea1c5fc368b1616730155e0041ac447d6301975fecda",
+		hnPubKey:   "0272da71976234ce833a6907425867b82e074d44ef907dfb4b3e21c1c2256ebcd1",
+		ephPrivKey: "99798858a1dc6a2c68637149a4b1dbfd1fdff5addd62a2142f06699ed7602529",
+		ephPubKey:  "039aab8376597021e855679a9778ea0b67396e68c66df32c0f41e9acca2da9b9d1",
+		sharedKey:  "6c7e6518980025b982fbb2ff746e3c2e85a196d252099a7ad23ea7b4c0959cae",
+		msin:       "001002086",
+		plainText:  "00012080f6",
+		cipherText: "46a33fc271",
+		macTag:     "6ac7dae96aa30a4d",
+		encKeyLen:  ProfileBEncKeyLen,
+		macKeyLen:  ProfileBMacKeyLen,
+		hashLen:    ProfileBHashLen,
+	},
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex in test vector %q: %v", s, err)
+	}
+	return b
+}
+
+func newCurve(t *testing.T, profile, privKey string) EllipticCurve {
+	t.Helper()
+	var c EllipticCurve
+	var err error
+	if profile == "A" {
+		c, err = NewX25519(privKey)
+	} else {
+		c, err = NewSecp256r1(privKey)
+	}
+	if err != nil {
+		t.Fatalf("failed to load private key %s: %v", privKey, err)
+	}
+	return c
+}
+
+func TestEciesHomeNetworkPublicKey(t *testing.T) {
+	for _, tc := range eciesTestSets {
+		t.Run(tc.name, func(t *testing.T) {
+			hn := newCurve(t, tc.profile, tc.hnPrivKey)
+			if got := hex.EncodeToString(hn.GetPubKey()); got != tc.hnPubKey {
+				t.Fatalf("home network public key = %s, want %s", got, tc.hnPubKey)
+			}
+		})
+	}
+}
+
+func TestEciesEphemeralPublicKey(t *testing.T) {
+	for _, tc := range eciesTestSets {
+		t.Run(tc.name, func(t *testing.T) {
+			eph := newCurve(t, tc.profile, tc.ephPrivKey)
+			if got := hex.EncodeToString(eph.GetPubKey()); got != tc.ephPubKey {
+				t.Fatalf("ephemeral public key = %s, want %s", got, tc.ephPubKey)
+			}
+		})
+	}
+}
+
+func TestEciesSharedKey(t *testing.T) {
+	for _, tc := range eciesTestSets {
+		t.Run(tc.name, func(t *testing.T) {
+			hn := newCurve(t, tc.profile, tc.hnPrivKey)
+			sharedKey, err := hn.GenerateSharedKey(mustDecodeHex(t, tc.ephPubKey))
+			if err != nil {
+				t.Fatalf("GenerateSharedKey failed: %v", err)
+			}
+			if got := hex.EncodeToString(sharedKey); got != tc.sharedKey {
+				t.Fatalf("home network shared key = %s, want %s", got, tc.sharedKey)
+			}
+
+			eph := newCurve(t, tc.profile, tc.ephPrivKey)
+			sharedKey, err = eph.GenerateSharedKey(mustDecodeHex(t, tc.hnPubKey))
+			if err != nil {
+				t.Fatalf("GenerateSharedKey failed: %v", err)
+			}
+			if got := hex.EncodeToString(sharedKey); got != tc.sharedKey {
+				t.Fatalf("UE shared key = %s, want %s", got, tc.sharedKey)
+			}
+		})
+	}
+}
+
+func TestEciesProtect(t *testing.T) {
+	for _, tc := range eciesTestSets {
+		t.Run(tc.name, func(t *testing.T) {
+			kdfKey := KDF(mustDecodeHex(t, tc.sharedKey), mustDecodeHex(t, tc.ephPubKey), tc.encKeyLen, tc.macKeyLen, tc.hashLen)
+			cipherText, macTag := protect(mustDecodeHex(t, tc.plainText), kdfKey)
+			if !bytes.Equal(cipherText, mustDecodeHex(t, tc.cipherText)) {
+				t.Fatalf("cipher text = %x, want %s", cipherText, tc.cipherText)
+			}
+			if !bytes.Equal(macTag, mustDecodeHex(t, tc.macTag)) {
+				t.Fatalf("MAC tag = %x, want %s", macTag, tc.macTag)
+			}
+		})
+	}
+}
+
+func TestEciesSchemeOutput(t *testing.T) {
+	for _, tc := range eciesTestSets {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Supi2Suci(tc.profile, tc.hnPubKey, tc.ephPrivKey, tc.msin)
+			if err != nil {
+				t.Fatalf("Supi2Suci failed: %v", err)
+			}
+			want := tc.ephPubKey + tc.cipherText + tc.macTag
+			if got != want {
+				t.Fatalf("scheme output = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestEciesDeconceal(t *testing.T) {
+	for _, tc := range eciesTestSets {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := "1"
+			if tc.profile == "B" {
+				scheme = "2"
+			}
+			suci := "suci-0-001-01-0-" + scheme + "-1-" + tc.ephPubKey + tc.cipherText + tc.macTag
+			got, err := Suci2Supi(suci, tc.hnPrivKey)
+			if err != nil {
+				t.Fatalf("Suci2Supi failed: %v", err)
+			}
+			if want := "imsi-00101" + tc.msin; got != want {
+				t.Fatalf("SUPI = %s, want %s", got, want)
+			}
+
+			tampered := suci[:len(suci)-1] + "0"
+			if suci[len(suci)-1] == '0' {
+				tampered = suci[:len(suci)-1] + "1"
+			}
+			if _, err := Suci2Supi(tampered, tc.hnPrivKey); err == nil {
+				t.Fatalf("Suci2Supi accepted a SUCI with a corrupted MAC tag")
+			}
+		})
+	}
+}
+
+func TestMalformedKeys(t *testing.T) {
+	malformed := []struct {
+		name string
+		fn   func() error
+	}{
+		{"X25519 private key not hex", func() error { _, err := NewX25519("zz"); return err }},
+		{"X25519 private key too short", func() error { _, err := NewX25519("c53c2220"); return err }},
+		{"secp256r1 private key not hex", func() error { _, err := NewSecp256r1("zz"); return err }},
+		{"secp256r1 private key zero", func() error { _, err := NewSecp256r1(strings.Repeat("00", 32)); return err }},
+		{"Profile A public key too short", func() error {
+			_, err := Supi2Suci("A", "5a8d3886", "", "001002086")
+			return err
+		}},
+		{"Profile B public key not compressed", func() error {
+			_, err := Supi2Suci("B", strings.Repeat("04", 33), "", "001002086")
+			return err
+		}},
+		{"Profile B public key not hex", func() error {
+			_, err := Supi2Suci("B", "zz", "", "001002086")
+			return err
+		}},
+		{"unknown profile", func() error {
+			_, err := Supi2Suci("C", eciesTestSets[0].hnPubKey, "", "001002086")
+			return err
+		}},
+	}
+	for _, tc := range malformed {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.fn(); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestProfileBEphemeralKey(t *testing.T) {
+	eph, err := NewEphemeralKey("B")
+	if err != nil {
+		t.Fatalf("NewEphemeralKey failed: %v", err)
+	}
+	if _, ok := eph.(*Secp256r1); !ok {
+		t.Fatalf("Profile B ephemeral key is %T, want *Secp256r1", eph)
+	}
+	if n := len(eph.GetPubKey()); n != 33 {
+		t.Fatalf("Profile B ephemeral public key is %d octets, want 33", n)
+	}
+}
+
+func TestMsinBcd(t *testing.T) {
+	cases := []struct {
+		msin string
+		bcd  string
+	}{
+		{"001002086", "00012080f6"},
+		{"123456789", "21436587f9"},
+		{"0123456789", "1032547698"},
+		{"9621156705", "6912517650"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.msin, func(t *testing.T) {
+			bcd, err := EncodeMsinBcd(tc.msin)
+			if err != nil {
+				t.Fatalf("EncodeMsinBcd failed: %v", err)
+			}
+			if got := hex.EncodeToString(bcd); got != tc.bcd {
+				t.Fatalf("EncodeMsinBcd(%s) = %s, want %s", tc.msin, got, tc.bcd)
+			}
+			msin, err := DecodeMsinBcd(bcd)
+			if err != nil {
+				t.Fatalf("DecodeMsinBcd failed: %v", err)
+			}
+			if msin != tc.msin {
+				t.Fatalf("DecodeMsinBcd(%s) = %s, want %s", tc.bcd, msin, tc.msin)
+			}
+		})
+	}
+
+	for _, msin := range []string{"", "12a4", "12 4"} {
+		if _, err := EncodeMsinBcd(msin); err == nil {
+			t.Fatalf("EncodeMsinBcd(%q) accepted an invalid MSIN", msin)
+		}
+	}
+}
+
+func TestSuciRoundTrip(t *testing.T) {
+	for _, tc := range eciesTestSets {
+		for _, msin := range []string{"123456789", "0123456789"} {
+			t.Run(tc.name+"/"+msin, func(t *testing.T) {
+				schemeOutput, err := Supi2Suci(tc.profile, tc.hnPubKey, "", msin)
+				if err != nil {
+					t.Fatalf("Supi2Suci failed: %v", err)
+				}
+				scheme := "1"
+				if tc.profile == "B" {
+					scheme = "2"
+				}
+				got, err := Suci2Supi("suci-0-208-93-0-"+scheme+"-1-"+schemeOutput, tc.hnPrivKey)
+				if err != nil {
+					t.Fatalf("Suci2Supi failed: %v", err)
+				}
+				if want := "imsi-20893" + msin; got != want {
+					t.Fatalf("SUPI = %s, want %s", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestNaiSuciRoundTrip(t *testing.T) {
+	for _, tc := range eciesTestSets {
+		t.Run(tc.name, func(t *testing.T) {
+			schemeOutput, err := Nai2Suci(tc.profile, tc.hnPubKey, "", "ue-0001")
+			if err != nil {
+				t.Fatalf("Nai2Suci failed: %v", err)
+			}
+			scheme := "1"
+			if tc.profile == "B" {
+				scheme = "2"
+			}
+			got, err := Suci2Supi("suci-1-my-realm.example.org-0000-"+scheme+"-1-"+schemeOutput, tc.hnPrivKey)
+			if err != nil {
+				t.Fatalf("Suci2Supi failed: %v", err)
+			}
+			if want := "nai-ue-0001@my-realm.example.org"; got != want {
+				t.Fatalf("SUPI = %s, want %s", got, want)
+			}
+		})
+	}
+}
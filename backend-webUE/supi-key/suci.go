@@ -0,0 +1,238 @@
+// supi-key/suci.go
+package supi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SUPI types (TS 24.501 9.11.3.4).
+const (
+	SupiTypeImsi = 0
+	SupiTypeNai  = 1
+)
+
+// Protection scheme identifiers (TS 33.501 Annex C).
+const (
+	NullSchemeId     = 0
+	ProfileASchemeId = 1
+	ProfileBSchemeId = 2
+)
+
+const SuciPrefix = "suci"
+
+// Suci is a SUCI in the TS 29.503 string form
+// suci-0-<mcc>-<mnc>-<routingIndicator>-<schemeId>-<keyId>-<schemeOutput> for an IMSI or
+// suci-1-<realm>-<routingIndicator>-<schemeId>-<keyId>-<schemeOutput> for a NAI.
+type Suci struct {
+	SupiType int
+	Mcc      string
+	Mnc      string
+	// Realm of a NAI-type SUPI
+	HomeNetworkIdentifier  string
+	RoutingIndicator       string
+	ProtectionSchemeId     int
+	HomeNetworkPublicKeyId int
+
+	// Scheme output of Profile A and Profile B
+	EphemeralPublicKey []byte
+	CipherText         []byte
+	MacTag             []byte
+
+	// Scheme output of the null scheme
+	Msin     string
+	Username string
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func isRoutingIndicator(s string) bool {
+	return len(s) <= 4 && isDigits(s)
+}
+
+// isNaiPart reports whether s can be the username or realm of a NAI.
+func isNaiPart(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r == '@' || r <= ' ' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// validateRealm checks the home network identifier of a NAI-type SUCI. No dash-separated
+// segment of the realm may look like a routing indicator, so that the realm can be
+// told apart from the fields that follow it.
+func validateRealm(realm string) error {
+	if !isNaiPart(realm) {
+		return fmt.Errorf("invalid NAI realm: %q", realm)
+	}
+	for _, segment := range strings.Split(realm, "-") {
+		if isRoutingIndicator(segment) {
+			return fmt.Errorf("unsupported NAI realm %q: numeric segment %q", realm, segment)
+		}
+	}
+	return nil
+}
+
+// parseSmallInt parses a decimal field of the SUCI, rejecting signs and leading zeros
+// so that String() reproduces the same text.
+func parseSmallInt(name, s string, max int) (int, error) {
+	if !isDigits(s) || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("invalid %s: %q", name, s)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n > max {
+		return 0, fmt.Errorf("invalid %s: %q", name, s)
+	}
+	return n, nil
+}
+
+// ParseSuci parses and validates a SUCI string.
+func ParseSuci(s string) (*Suci, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 7 || parts[0] != SuciPrefix {
+		return nil, fmt.Errorf("invalid SUCI format: %s", s)
+	}
+
+	suci := &Suci{}
+	var err error
+	if suci.SupiType, err = parseSmallInt("SUPI type", parts[1], 7); err != nil {
+		return nil, err
+	}
+
+	// fields holds routing indicator, scheme, key ID and scheme output
+	var fields []string
+	switch suci.SupiType {
+	case SupiTypeImsi:
+		if len(parts) != 8 {
+			return nil, fmt.Errorf("invalid SUCI format: %s", s)
+		}
+		suci.Mcc, suci.Mnc = parts[2], parts[3]
+		if len(suci.Mcc) != 3 || !isDigits(suci.Mcc) {
+			return nil, fmt.Errorf("invalid MCC: %q", suci.Mcc)
+		}
+		if (len(suci.Mnc) != 2 && len(suci.Mnc) != 3) || !isDigits(suci.Mnc) {
+			return nil, fmt.Errorf("invalid MNC: %q", suci.Mnc)
+		}
+		fields = parts[4:]
+	case SupiTypeNai:
+		// The realm may contain dashes; it ends before the first routing indicator
+		i := 3
+		for i < len(parts) && !isRoutingIndicator(parts[i]) {
+			i++
+		}
+		if len(parts)-i < 4 {
+			return nil, fmt.Errorf("invalid SUCI format: %s", s)
+		}
+		suci.HomeNetworkIdentifier = strings.Join(parts[2:i], "-")
+		if err := validateRealm(suci.HomeNetworkIdentifier); err != nil {
+			return nil, err
+		}
+		fields = parts[i:]
+	default:
+		return nil, fmt.Errorf("unsupported SUPI type: %d", suci.SupiType)
+	}
+
+	suci.RoutingIndicator = fields[0]
+	if !isRoutingIndicator(suci.RoutingIndicator) {
+		return nil, fmt.Errorf("invalid routing indicator: %q", suci.RoutingIndicator)
+	}
+	if suci.ProtectionSchemeId, err = parseSmallInt("protection scheme", fields[1], 15); err != nil {
+		return nil, err
+	}
+	if suci.HomeNetworkPublicKeyId, err = parseSmallInt("home network public key ID", fields[2], 255); err != nil {
+		return nil, err
+	}
+
+	// Only a null scheme NAI username can contain dashes
+	if err := suci.SetSchemeOutput(strings.Join(fields[3:], "-")); err != nil {
+		return nil, err
+	}
+	return suci, nil
+}
+
+// SetSchemeOutput validates the scheme output field against the protection scheme
+// and splits it into its components.
+func (s *Suci) SetSchemeOutput(schemeOutput string) error {
+	var ephPubKeyLen int
+	switch s.ProtectionSchemeId {
+	case NullSchemeId:
+		if s.HomeNetworkPublicKeyId != 0 {
+			return fmt.Errorf("null scheme SUCI with home network public key ID %d", s.HomeNetworkPublicKeyId)
+		}
+		if s.SupiType == SupiTypeNai {
+			if !isNaiPart(schemeOutput) {
+				return fmt.Errorf("invalid null scheme NAI username: %q", schemeOutput)
+			}
+			s.Username = schemeOutput
+			return nil
+		}
+		if len(schemeOutput) > 10 || !isDigits(schemeOutput) {
+			return fmt.Errorf("invalid null scheme MSIN: %q", schemeOutput)
+		}
+		s.Msin = schemeOutput
+		return nil
+	case ProfileASchemeId:
+		ephPubKeyLen = ProfileAPubKeyLen
+	case ProfileBSchemeId:
+		ephPubKeyLen = ProfileBPubKeyLen
+	default:
+		return fmt.Errorf("unsupported protection scheme: %d", s.ProtectionSchemeId)
+	}
+
+	output, err := hex.DecodeString(schemeOutput)
+	if err != nil {
+		return fmt.Errorf("invalid scheme output: %v", err)
+	}
+	if len(output) <= ephPubKeyLen+ProfileAMacLen {
+		return fmt.Errorf("scheme output too short: %d octets", len(output))
+	}
+	if s.ProtectionSchemeId == ProfileBSchemeId && output[0] != 0x02 && output[0] != 0x03 {
+		return fmt.Errorf("Profile B ephemeral public key is not a compressed point")
+	}
+	s.EphemeralPublicKey = output[:ephPubKeyLen]
+	s.CipherText = output[ephPubKeyLen : len(output)-ProfileAMacLen]
+	s.MacTag = output[len(output)-ProfileAMacLen:]
+	return nil
+}
+
+// SchemeOutput returns the scheme output field as it appears in the SUCI string.
+func (s *Suci) SchemeOutput() string {
+	if s.ProtectionSchemeId == NullSchemeId {
+		if s.SupiType == SupiTypeNai {
+			return s.Username
+		}
+		return s.Msin
+	}
+	return hex.EncodeToString(s.EphemeralPublicKey) + hex.EncodeToString(s.CipherText) + hex.EncodeToString(s.MacTag)
+}
+
+// String formats the SUCI in its string form.
+func (s *Suci) String() string {
+	fields := []string{SuciPrefix, strconv.Itoa(s.SupiType)}
+	if s.SupiType == SupiTypeNai {
+		fields = append(fields, s.HomeNetworkIdentifier)
+	} else {
+		fields = append(fields, s.Mcc, s.Mnc)
+	}
+	fields = append(fields,
+		s.RoutingIndicator,
+		strconv.Itoa(s.ProtectionSchemeId),
+		strconv.Itoa(s.HomeNetworkPublicKeyId),
+		s.SchemeOutput(),
+	)
+	return strings.Join(fields, "-")
+}
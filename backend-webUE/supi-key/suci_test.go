@@ -0,0 +1,100 @@
+package supi
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	profileASuci = "suci-0-274-012-0-1-1-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcb02352410cddd9e730ef3fa87"
+	profileBSuci = "suci-0-001-01-0-2-2-039aab8376597021e855679a9778ea0b67396e68c66df32c0f41e9acca2da9b9d146a33fc2716ac7dae96aa30a4d"
+	nullSuci     = "suci-0-208-93-0000-0-0-0123456789"
+	naiSuci      = "suci-1-nai.5gc.mnc093.mcc208.3gppnetwork.org-0000-1-1-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcb02352410cddd9e730ef3fa87"
+	naiNullSuci  = "suci-1-my-realm.example.org-0-0-0-ue-0001"
+)
+
+func TestParseSuci(t *testing.T) {
+	suci, err := ParseSuci(profileBSuci)
+	if err != nil {
+		t.Fatalf("ParseSuci failed: %v", err)
+	}
+	if suci.Mcc != "001" || suci.Mnc != "01" || suci.RoutingIndicator != "0" {
+		t.Fatalf("unexpected PLMN or routing indicator: %+v", suci)
+	}
+	if suci.ProtectionSchemeId != ProfileBSchemeId || suci.HomeNetworkPublicKeyId != 2 {
+		t.Fatalf("unexpected scheme or key ID: %+v", suci)
+	}
+	if len(suci.EphemeralPublicKey) != ProfileBPubKeyLen || len(suci.CipherText) != 5 || len(suci.MacTag) != ProfileBMacLen {
+		t.Fatalf("unexpected scheme output split: %+v", suci)
+	}
+
+	suci, err = ParseSuci(naiNullSuci)
+	if err != nil {
+		t.Fatalf("ParseSuci failed: %v", err)
+	}
+	if suci.HomeNetworkIdentifier != "my-realm.example.org" || suci.Username != "ue-0001" || suci.RoutingIndicator != "0" {
+		t.Fatalf("unexpected NAI SUCI fields: %+v", suci)
+	}
+
+	for _, s := range []string{profileASuci, profileBSuci, nullSuci, naiSuci, naiNullSuci} {
+		suci, err := ParseSuci(s)
+		if err != nil {
+			t.Fatalf("ParseSuci(%s) failed: %v", s, err)
+		}
+		if got := suci.String(); got != s {
+			t.Fatalf("String() = %s, want %s", got, s)
+		}
+	}
+}
+
+func TestParseSuciMalformed(t *testing.T) {
+	malformed := []string{
+		"",
+		"imsi-001010123456789",
+		"suci-0-01-001-0-1-1-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcb02352410cddd9e730ef3fa87",
+		"suci-0-274-012-0-1-1-5a8d38864820197c3394b92613b20b91633cbd897119273bf8e4a6f4eec0a650-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcb02352410cddd9e730ef3fa87",
+		"suci-0-274-012-0-1-256-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcb02352410cddd9e730ef3fa87",
+		"suci-0-274-012-0-1-01-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcb02352410cddd9e730ef3fa87",
+		"suci-0-274-012-00000-1-1-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcb02352410cddd9e730ef3fa87",
+		"suci-0-274-012-0-3-1-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcb02352410cddd9e730ef3fa87",
+		"suci-0-274-012-0-1-1-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcddd9e730ef3fa87",
+		"suci-0-001-01-0-2-2-049aab8376597021e855679a9778ea0b67396e68c66df32c0f41e9acca2da9b9d146a33fc2716ac7dae96aa30a4d",
+		"suci-0-208-93-0000-0-1-0123456789",
+		"suci-0-208-93-0000-0-0-01234567890",
+		"suci-0-208-93-0000-0-0-",
+		"suci-1-0000-1-1-b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457dcb02352410cddd9e730ef3fa87",
+		"suci-1-realm-12-x.org-0000-0-0-user",
+		"suci-1-user@realm.org-0000-0-0-user",
+		"suci-1-realm.org-0000-0-0-us er",
+		"suci-1-realm.org-0000-1-1-b2e9-2f83",
+		"suci-2-realm.org-0000-0-0-user",
+	}
+	for _, s := range malformed {
+		if _, err := ParseSuci(s); err == nil {
+			t.Errorf("ParseSuci(%q) accepted a malformed SUCI", s)
+		}
+	}
+}
+
+func FuzzParseSuci(f *testing.F) {
+	for _, s := range []string{profileASuci, profileBSuci, nullSuci, naiSuci, naiNullSuci, "suci-0-208-93-0-1-1-00"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		suci, err := ParseSuci(s)
+		if err != nil {
+			return
+		}
+		formatted := suci.String()
+		reparsed, err := ParseSuci(formatted)
+		if err != nil {
+			t.Fatalf("ParseSuci(%q) rejected the output of String() for %q: %v", formatted, s, err)
+		}
+		if !reflect.DeepEqual(suci, reparsed) {
+			t.Fatalf("round trip mismatch for %q: %+v != %+v", s, suci, reparsed)
+		}
+		if again := reparsed.String(); again != formatted {
+			t.Fatalf("String() is not stable: %q != %q", again, formatted)
+		}
+	})
+}
@@ -0,0 +1,263 @@
+// tuak/tuak.go
+//
+// Package tuak implements the 3GPP TUAK authentication and key generation functions
+// f1, f1*, f2, f3, f4, f5 and f5* (TS 35.231) and the derivation of TOPc from the
+// operator variant algorithm configuration field TOP.
+package tuak
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	TopLen  = 32 // octets, TOP and TOPc
+	RandLen = 16 // octets
+	SqnLen  = 6  // octets
+	AmfLen  = 2  // octets
+	AkLen   = 6  // octets
+)
+
+// algorithmName is ALGONAME of TS 35.231 clause 6.2
+var algorithmName = []byte("TUAK1.0")
+
+// Params are the output lengths in octets and the number of Keccak iterations of a TUAK instance
+type Params struct {
+	MacLen     int // 8, 16 or 32
+	ResLen     int // 4, 8, 16 or 32
+	CkLen      int // 16 or 32
+	IkLen      int // 16 or 32
+	Iterations int // at least 1
+}
+
+// DefaultParams are 64-bit MAC and RES, 128-bit CK and IK and a single Keccak iteration
+var DefaultParams = Params{MacLen: 8, ResLen: 8, CkLen: 16, IkLen: 16, Iterations: 1}
+
+// Validate checks that the lengths are among the ones TS 35.231 allows
+func (p Params) Validate() error {
+	if _, ok := macInstance[p.MacLen]; !ok {
+		return fmt.Errorf("unsupported MAC length %d", p.MacLen)
+	}
+	if _, ok := resInstance[p.ResLen]; !ok {
+		return fmt.Errorf("unsupported RES length %d", p.ResLen)
+	}
+	if p.CkLen != 16 && p.CkLen != 32 {
+		return fmt.Errorf("unsupported CK length %d", p.CkLen)
+	}
+	if p.IkLen != 16 && p.IkLen != 32 {
+		return fmt.Errorf("unsupported IK length %d", p.IkLen)
+	}
+	if p.Iterations < 1 {
+		return fmt.Errorf("Keccak iterations must be at least 1, got %d", p.Iterations)
+	}
+	return nil
+}
+
+// INSTANCE bits selecting the function and its output lengths (TS 35.231 clause 6.2)
+var (
+	macInstance = map[int]byte{8: 0x08, 16: 0x10, 32: 0x20}
+	resInstance = map[int]byte{4: 0x00, 8: 0x08, 16: 0x10, 32: 0x20}
+)
+
+const (
+	instanceF1     = 0x00
+	instanceF1Star = 0x80
+	instanceF2345  = 0x40
+	instanceF5Star = 0xc0
+	instanceCk256  = 0x04
+	instanceIk256  = 0x02
+	instanceK256   = 0x01
+)
+
+// Tuak holds a subscriber key K, its TOPc and the output lengths
+type Tuak struct {
+	k      []byte
+	topc   []byte
+	params Params
+}
+
+// New creates a TUAK instance from K and TOP, deriving TOPc
+func New(k, top []byte, params Params) (*Tuak, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	topc, err := GenerateTopc(k, top, params.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithTopc(k, topc, params)
+}
+
+// NewWithTopc creates a TUAK instance from K and TOPc
+func NewWithTopc(k, topc []byte, params Params) (*Tuak, error) {
+	if err := validateKey(k); err != nil {
+		return nil, err
+	}
+	if len(topc) != TopLen {
+		return nil, fmt.Errorf("TOPc must be %d octets, got %d", TopLen, len(topc))
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &Tuak{
+		k:      append([]byte(nil), k...),
+		topc:   append([]byte(nil), topc...),
+		params: params,
+	}, nil
+}
+
+// GenerateTopc derives TOPc from K and TOP with the given number of Keccak iterations
+func GenerateTopc(k, top []byte, iterations int) ([]byte, error) {
+	if err := validateKey(k); err != nil {
+		return nil, err
+	}
+	if len(top) != TopLen {
+		return nil, fmt.Errorf("TOP must be %d octets, got %d", TopLen, len(top))
+	}
+	if iterations < 1 {
+		return nil, fmt.Errorf("Keccak iterations must be at least 1, got %d", iterations)
+	}
+
+	out := core(k, top, keyInstance(k), nil, nil, nil, iterations)
+	return reversed(out[:TopLen]), nil
+}
+
+// GenerateTopcHex derives TOPc from hex encoded K and TOP with a single Keccak iteration
+// and returns it hex encoded
+func GenerateTopcHex(kHex, topHex string) (string, error) {
+	k, err := hex.DecodeString(kHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid K: %v", err)
+	}
+	top, err := hex.DecodeString(topHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid TOP: %v", err)
+	}
+	topc, err := GenerateTopc(k, top, DefaultParams.Iterations)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(topc), nil
+}
+
+// Topc returns the TOPc in use
+func (t *Tuak) Topc() []byte {
+	return append([]byte(nil), t.topc...)
+}
+
+// F1 computes the network authentication code MAC-A
+func (t *Tuak) F1(rand, sqn, amf []byte) ([]byte, error) {
+	return t.f1(instanceF1, rand, sqn, amf)
+}
+
+// F1Star computes the resynchronisation authentication code MAC-S
+func (t *Tuak) F1Star(rand, sqn, amf []byte) ([]byte, error) {
+	return t.f1(instanceF1Star, rand, sqn, amf)
+}
+
+// F2345 computes the response RES (f2), the cipher key CK (f3), the integrity key IK (f4)
+// and the anonymity key AK (f5)
+func (t *Tuak) F2345(rand []byte) (res, ck, ik, ak []byte, err error) {
+	if len(rand) != RandLen {
+		return nil, nil, nil, nil, fmt.Errorf("RAND must be %d octets, got %d", RandLen, len(rand))
+	}
+
+	instance := instanceF2345 | resInstance[t.params.ResLen] | keyInstance(t.k)
+	if t.params.CkLen == 32 {
+		instance |= instanceCk256
+	}
+	if t.params.IkLen == 32 {
+		instance |= instanceIk256
+	}
+
+	out := core(t.k, t.topc, instance, rand, nil, nil, t.params.Iterations)
+	res = reversed(out[:t.params.ResLen])
+	ck = reversed(out[32 : 32+t.params.CkLen])
+	ik = reversed(out[64 : 64+t.params.IkLen])
+	ak = reversed(out[96 : 96+AkLen])
+	return res, ck, ik, ak, nil
+}
+
+// F5Star computes the anonymity key AK used for resynchronisation
+func (t *Tuak) F5Star(rand []byte) ([]byte, error) {
+	if len(rand) != RandLen {
+		return nil, fmt.Errorf("RAND must be %d octets, got %d", RandLen, len(rand))
+	}
+	out := core(t.k, t.topc, instanceF5Star|keyInstance(t.k), rand, nil, nil, t.params.Iterations)
+	return reversed(out[96 : 96+AkLen]), nil
+}
+
+// f1 computes MAC-A or MAC-S depending on the instance
+func (t *Tuak) f1(instance byte, rand, sqn, amf []byte) ([]byte, error) {
+	if len(rand) != RandLen {
+		return nil, fmt.Errorf("RAND must be %d octets, got %d", RandLen, len(rand))
+	}
+	if len(sqn) != SqnLen {
+		return nil, fmt.Errorf("SQN must be %d octets, got %d", SqnLen, len(sqn))
+	}
+	if len(amf) != AmfLen {
+		return nil, fmt.Errorf("AMF must be %d octets, got %d", AmfLen, len(amf))
+	}
+
+	instance |= macInstance[t.params.MacLen] | keyInstance(t.k)
+	out := core(t.k, t.topc, instance, rand, amf, sqn, t.params.Iterations)
+	return reversed(out[:t.params.MacLen]), nil
+}
+
+// core fills the 1600-bit Keccak state with TOP(c), INSTANCE, ALGONAME, RAND, AMF, SQN and K
+// and applies the permutation. The fields are stored least significant octet first; RAND,
+// AMF and SQN are zero where a function does not take them.
+func core(k, top []byte, instance byte, rand, amf, sqn []byte, iterations int) []byte {
+	state := make([]byte, 200)
+	reverseInto(state[0:32], top)
+	state[32] = instance
+	reverseInto(state[33:40], algorithmName)
+	if rand != nil {
+		reverseInto(state[40:56], rand)
+	}
+	if amf != nil {
+		reverseInto(state[56:58], amf)
+	}
+	if sqn != nil {
+		reverseInto(state[58:64], sqn)
+	}
+	reverseInto(state[64:64+len(k)], k)
+
+	// Padding of the 1088-bit rate
+	state[96] = 0x1f
+	state[135] = 0x80
+
+	for i := 0; i < iterations; i++ {
+		keccakF1600(state)
+	}
+	return state
+}
+
+// keyInstance returns the INSTANCE bit of the key length
+func keyInstance(k []byte) byte {
+	if len(k) == 32 {
+		return instanceK256
+	}
+	return 0
+}
+
+func validateKey(k []byte) error {
+	if len(k) != 16 && len(k) != 32 {
+		return fmt.Errorf("K must be 16 or 32 octets, got %d", len(k))
+	}
+	return nil
+}
+
+// reverseInto copies src into dst in reverse octet order
+func reverseInto(dst, src []byte) {
+	for i := range src {
+		dst[i] = src[len(src)-1-i]
+	}
+}
+
+// reversed returns a copy of b in reverse octet order
+func reversed(b []byte) []byte {
+	out := make([]byte, len(b))
+	reverseInto(out, b)
+	return out
+}
@@ -0,0 +1,151 @@
+package tuak
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TS 35.232 test set 1
+const (
+	testK      = "abababababababababababababababab"
+	testRand   = "42424242424242424242424242424242"
+	testSqn    = "111111111111"
+	testAmf    = "ffff"
+	testTop    = "5555555555555555555555555555555555555555555555555555555555555555"
+	testTopc   = "bd04d9530e87513c5d837ac2ad954623a8e2330c115305a73eb45d1f40cccbff"
+	testF1     = "f9a54e6aeaa8618d"
+	testF1Star = "e94b4dc6c7297df3"
+	testF2     = "657acd64"
+	testF3     = "d71a1e5c6caffe986a26f783e5c78be1"
+	testF4     = "be849fa2564f869aecee6f62d4337e72"
+	testF5     = "719f1e9b9054"
+)
+
+var testParams = Params{MacLen: 8, ResLen: 4, CkLen: 16, IkLen: 16, Iterations: 1}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+func checkHex(t *testing.T, name string, got []byte, want string) {
+	t.Helper()
+	if hex.EncodeToString(got) != want {
+		t.Errorf("%s = %x, want %s", name, got, want)
+	}
+}
+
+func TestKeccakF1600(t *testing.T) {
+	// SHA3-256 of the empty message is a single permutation of the padded state
+	state := make([]byte, 200)
+	state[0] = 0x06
+	state[135] = 0x80
+	keccakF1600(state)
+	checkHex(t, "SHA3-256(\"\")", state[:32], "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a")
+}
+
+func TestTuak(t *testing.T) {
+	k, rand := mustHex(t, testK), mustHex(t, testRand)
+	sqn, amf := mustHex(t, testSqn), mustHex(t, testAmf)
+
+	topc, err := GenerateTopc(k, mustHex(t, testTop), 1)
+	if err != nil {
+		t.Fatalf("GenerateTopc failed: %v", err)
+	}
+	checkHex(t, "TOPc", topc, testTopc)
+
+	tk, err := New(k, mustHex(t, testTop), testParams)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if !bytes.Equal(tk.Topc(), topc) {
+		t.Fatalf("Topc() = %x, want %x", tk.Topc(), topc)
+	}
+
+	macA, err := tk.F1(rand, sqn, amf)
+	if err != nil {
+		t.Fatalf("F1 failed: %v", err)
+	}
+	checkHex(t, "f1", macA, testF1)
+	macS, err := tk.F1Star(rand, sqn, amf)
+	if err != nil {
+		t.Fatalf("F1Star failed: %v", err)
+	}
+	checkHex(t, "f1*", macS, testF1Star)
+
+	res, ck, ik, ak, err := tk.F2345(rand)
+	if err != nil {
+		t.Fatalf("F2345 failed: %v", err)
+	}
+	checkHex(t, "f2", res, testF2)
+	checkHex(t, "f3", ck, testF3)
+	checkHex(t, "f4", ik, testF4)
+	checkHex(t, "f5", ak, testF5)
+
+	akStar, err := tk.F5Star(rand)
+	if err != nil {
+		t.Fatalf("F5Star failed: %v", err)
+	}
+	if len(akStar) != AkLen || bytes.Equal(akStar, ak) {
+		t.Fatalf("f5* = %x is not a distinct %d octet AK", akStar, AkLen)
+	}
+}
+
+func TestOutputLengths(t *testing.T) {
+	k := bytes.Repeat([]byte{0xab}, 32)
+	params := Params{MacLen: 32, ResLen: 32, CkLen: 32, IkLen: 32, Iterations: 2}
+	tk, err := New(k, mustHex(t, testTop), params)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	macA, err := tk.F1(mustHex(t, testRand), mustHex(t, testSqn), mustHex(t, testAmf))
+	if err != nil {
+		t.Fatalf("F1 failed: %v", err)
+	}
+	res, ck, ik, ak, err := tk.F2345(mustHex(t, testRand))
+	if err != nil {
+		t.Fatalf("F2345 failed: %v", err)
+	}
+	if len(macA) != 32 || len(res) != 32 || len(ck) != 32 || len(ik) != 32 || len(ak) != AkLen {
+		t.Fatalf("unexpected output lengths: MAC %d, RES %d, CK %d, IK %d, AK %d", len(macA), len(res), len(ck), len(ik), len(ak))
+	}
+}
+
+func TestInvalidInput(t *testing.T) {
+	k, top := mustHex(t, testK), mustHex(t, testTop)
+	if _, err := New(k[:15], top, DefaultParams); err == nil {
+		t.Error("New accepted a short K")
+	}
+	if _, err := New(k, top[:31], DefaultParams); err == nil {
+		t.Error("New accepted a short TOP")
+	}
+	for _, params := range []Params{
+		{MacLen: 4, ResLen: 8, CkLen: 16, IkLen: 16, Iterations: 1},
+		{MacLen: 8, ResLen: 2, CkLen: 16, IkLen: 16, Iterations: 1},
+		{MacLen: 8, ResLen: 8, CkLen: 8, IkLen: 16, Iterations: 1},
+		{MacLen: 8, ResLen: 8, CkLen: 16, IkLen: 16, Iterations: 0},
+	} {
+		if _, err := New(k, top, params); err == nil {
+			t.Errorf("New accepted params %+v", params)
+		}
+	}
+
+	tk, err := New(k, top, DefaultParams)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := tk.F1(k[:15], mustHex(t, testSqn), mustHex(t, testAmf)); err == nil {
+		t.Error("F1 accepted a short RAND")
+	}
+	if _, _, _, _, err := tk.F2345(k[:15]); err == nil {
+		t.Error("F2345 accepted a short RAND")
+	}
+	if _, err := GenerateTopcHex(testK, "55"); err == nil {
+		t.Error("GenerateTopcHex accepted a short TOP")
+	}
+}
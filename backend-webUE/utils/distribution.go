@@ -0,0 +1,232 @@
+// utils/distribution.go
+package utils
+
+import (
+	"backend-webUE/models"
+	"fmt"
+)
+
+// batchPlan holds, for every UE of a batch, the index of the share it was assigned in each
+// list of the batch distribution. A nil list leaves that choice to the operator.
+type batchPlan struct {
+	schemes, opTypes, slices, sessionTypes []int
+}
+
+// Apportion splits count UEs into shares of the given percentages, which must add up to 100.
+// Every share must be a whole number of UEs so the counts match the percentages exactly: a split
+// that would need rounding, like 70% of 5 UEs, is rejected.
+func Apportion(percents []int, count int) ([]int, error) {
+	if err := checkPercents(percents); err != nil {
+		return nil, err
+	}
+
+	counts := make([]int, len(percents))
+	for i, percent := range percents {
+		if percent*count%100 != 0 {
+			return nil, fmt.Errorf("%d%% of %d UEs is not a whole number of UEs", percent, count)
+		}
+		counts[i] = percent * count / 100
+	}
+	return counts, nil
+}
+
+// checkPercents checks that shares are not negative and add up to 100%
+func checkPercents(percents []int) error {
+	total := 0
+	for _, percent := range percents {
+		if percent < 0 {
+			return fmt.Errorf("invalid share of %d%%", percent)
+		}
+		total += percent
+	}
+	if total != 100 {
+		return fmt.Errorf("shares add up to %d%%, not 100%%", total)
+	}
+	return nil
+}
+
+// planShares returns the share index of each of count UEs, in the apportioned numbers and
+// shuffled with the operator's entropy so shares are not tied to the SUPI order
+func (o *Operator) planShares(percents []int, count int) ([]int, error) {
+	if len(percents) == 0 {
+		return nil, nil
+	}
+	counts, err := Apportion(percents, count)
+	if err != nil {
+		return nil, err
+	}
+	plan := make([]int, 0, count)
+	for share, n := range counts {
+		for j := 0; j < n; j++ {
+			plan = append(plan, share)
+		}
+	}
+	for i := len(plan) - 1; i > 0; i-- {
+		j, err := randomIntn(o.entropy(), i+1)
+		if err != nil {
+			return nil, err
+		}
+		plan[i], plan[j] = plan[j], plan[i]
+	}
+	return plan, nil
+}
+
+// planBatch checks the operator's batch distribution and assigns its shares to count UEs
+func (o *Operator) planBatch(count int) (*batchPlan, error) {
+	dist := o.config.Distribution
+	if dist == nil {
+		return nil, nil
+	}
+	if err := o.validateDistribution(dist); err != nil {
+		return nil, err
+	}
+
+	var plan batchPlan
+	plans := []*[]int{&plan.schemes, &plan.opTypes, &plan.slices, &plan.sessionTypes}
+	for i, shares := range distributionShares(dist) {
+		var err error
+		if *plans[i], err = o.planShares(shares.percents, count); err != nil {
+			return nil, fmt.Errorf("invalid %s distribution: %v", shares.name, err)
+		}
+	}
+	return &plan, nil
+}
+
+// ValidateDistribution checks the batch distribution of an operator configuration without a batch
+// size: every list that is set adds up to 100% and every share can be generated by the operator
+func ValidateDistribution(config *OperatorConfig) error {
+	dist := config.Distribution
+	if dist == nil {
+		return nil
+	}
+	if err := NewOperator(config).validateDistribution(dist); err != nil {
+		return err
+	}
+	for _, shares := range distributionShares(dist) {
+		if len(shares.percents) == 0 {
+			continue
+		}
+		if err := checkPercents(shares.percents); err != nil {
+			return fmt.Errorf("invalid %s distribution: %v", shares.name, err)
+		}
+	}
+	return nil
+}
+
+// shareList is the name and the percentages of one list of a batch distribution
+type shareList struct {
+	name     string
+	percents []int
+}
+
+// distributionShares returns the scheme, OP type, slice and session type lists of a distribution, in that order
+func distributionShares(dist *models.BatchDistribution) []shareList {
+	return []shareList{
+		{"scheme", percentsOf(len(dist.Schemes), func(i int) int { return dist.Schemes[i].Percent })},
+		{"OP type", percentsOf(len(dist.OpTypes), func(i int) int { return dist.OpTypes[i].Percent })},
+		{"slice", percentsOf(len(dist.Slices), func(i int) int { return dist.Slices[i].Percent })},
+		{"session type", percentsOf(len(dist.SessionTypes), func(i int) int { return dist.SessionTypes[i].Percent })},
+	}
+}
+
+// percentsOf collects the percentages of n shares
+func percentsOf(n int, percent func(i int) int) []int {
+	percents := make([]int, n)
+	for i := range percents {
+		percents[i] = percent(i)
+	}
+	return percents
+}
+
+// validateDistribution checks that every share of a distribution can be generated by the operator
+func (o *Operator) validateDistribution(dist *models.BatchDistribution) error {
+	for _, share := range dist.Schemes {
+		if _, err := o.schemeProfile(share.Scheme); err != nil {
+			return err
+		}
+	}
+	for _, share := range dist.OpTypes {
+		if _, err := o.isOpc(share.OpType); err != nil {
+			return err
+		}
+	}
+	for _, share := range dist.Slices {
+		if len(o.config.UeConfiguredNssai) > 0 && !ContainsSnssai(o.config.UeConfiguredNssai, share.Slice) {
+			return fmt.Errorf("slice SST %d SD %q is not in the configured NSSAI", share.Slice.Sst, share.Slice.Sd)
+		}
+	}
+	for _, share := range dist.SessionTypes {
+		if err := ValidateSessionType(share.Type); err != nil {
+			return err
+		}
+	}
+	if len(dist.SessionTypes) > 0 && len(o.config.Sessions) == 0 {
+		return fmt.Errorf("a session type distribution needs configured sessions")
+	}
+	return nil
+}
+
+// schemeProfile returns the profile of a protection scheme. With a key registry the key is
+// resolved later, so only the scheme is set.
+func (o *Operator) schemeProfile(scheme int) (models.Profile, error) {
+	switch {
+	case scheme == NULL_SCHEME:
+		return models.Profile{Scheme: NULL_SCHEME}, nil
+	case scheme != A_SCHEME && scheme != B_SCHEME:
+		return models.Profile{}, fmt.Errorf("unsupported profile scheme: %d", scheme)
+	case o.config.KeySource != nil:
+		return models.Profile{Scheme: scheme}, nil
+	}
+	profile, ok := findProfile(o.config.Profiles, scheme)
+	if !ok {
+		return models.Profile{}, fmt.Errorf("no profile configured for scheme: %d", scheme)
+	}
+	return profile, nil
+}
+
+// isOpc reports whether an OP type of a distribution stores the derived OPc or TOPc.
+// OP types follow the operator's algorithm: OP and OPC for Milenage, TOP and TOPC for TUAK.
+func (o *Operator) isOpc(opType string) (bool, error) {
+	derived := map[string]bool{OP: false, OPC: true}
+	if o.config.AuthAlgorithm == TUAK {
+		derived = map[string]bool{TOP: false, TOPC: true}
+	}
+	isOpc, ok := derived[opType]
+	if !ok {
+		return false, fmt.Errorf("OP type %q does not belong to %s", opType, o.authAlgorithm())
+	}
+	return isOpc, nil
+}
+
+// authAlgorithm returns the operator's authentication algorithm, MILENAGE by default
+func (o *Operator) authAlgorithm() string {
+	if o.config.AuthAlgorithm == "" {
+		return MILENAGE
+	}
+	return o.config.AuthAlgorithm
+}
+
+// applySlice makes slice the default S-NSSAI of a UE and the slice of its sessions. A UE without
+// a configured NSSAI gets the slice as its configured NSSAI too.
+func applySlice(ue *models.UeProfile, slice models.Snssai) {
+	ue.UeDefaultNssai = []models.Snssai{slice}
+	if len(ue.UeConfiguredNssai) == 0 {
+		ue.UeConfiguredNssai = []models.Snssai{slice}
+	}
+	sessions := make([]models.Sessions, len(ue.Sessions))
+	for i, session := range ue.Sessions {
+		session.Slice = slice
+		sessions[i] = session
+	}
+	ue.Sessions = sessions
+}
+
+// applySessionType sets the session type of all sessions of a UE
+func applySessionType(ue *models.UeProfile, sessionType string) {
+	sessions := make([]models.Sessions, len(ue.Sessions))
+	for i, session := range ue.Sessions {
+		session.Type = sessionType
+		sessions[i] = session
+	}
+	ue.Sessions = sessions
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"backend-webUE/models"
+	"testing"
+)
+
+func TestApportion(t *testing.T) {
+	for _, tc := range []struct {
+		percents []int
+		count    int
+		want     []int
+	}{
+		{[]int{70, 30}, 10, []int{7, 3}},
+		{[]int{40, 60}, 5, []int{2, 3}},
+		{[]int{25, 75}, 4, []int{1, 3}},
+		{[]int{0, 100}, 3, []int{0, 3}},
+		{[]int{100}, 3, []int{3}},
+	} {
+		counts, err := Apportion(tc.percents, tc.count)
+		if err != nil {
+			t.Fatalf("Apportion(%v, %d) failed: %v", tc.percents, tc.count, err)
+		}
+		for i := range tc.want {
+			if counts[i] != tc.want[i] {
+				t.Fatalf("Apportion(%v, %d) = %v, want %v", tc.percents, tc.count, counts, tc.want)
+			}
+		}
+	}
+
+	if _, err := Apportion([]int{70, 20}, 10); err == nil {
+		t.Fatal("Apportion accepted shares adding up to 90%")
+	}
+	if _, err := Apportion([]int{110, -10}, 10); err == nil {
+		t.Fatal("Apportion accepted a negative share")
+	}
+	// Shares that are not whole numbers of UEs cannot be generated exactly
+	for _, tc := range []struct {
+		percents []int
+		count    int
+	}{
+		{[]int{70, 30}, 5},
+		{[]int{25, 75}, 10},
+		{[]int{33, 33, 34}, 10},
+	} {
+		if counts, err := Apportion(tc.percents, tc.count); err == nil {
+			t.Errorf("Apportion(%v, %d) = %v, want an error", tc.percents, tc.count, counts)
+		}
+	}
+}
+
+func TestGenerateUesWithDistribution(t *testing.T) {
+	sst1 := models.Snssai{Sst: 1, Sd: "010203"}
+	sst2 := models.Snssai{Sst: 2, Sd: "010203"}
+	config := seededConfig(23)
+	config.UeConfiguredNssai = []models.Snssai{sst1, sst2}
+	config.Sessions = []models.Sessions{{Type: SESSION_TYPE_IPV4, Apn: "internet", Slice: sst1}}
+	config.Distribution = &models.BatchDistribution{
+		Schemes:      []models.SchemeShare{{Scheme: A_SCHEME, Percent: 70}, {Scheme: B_SCHEME, Percent: 30}},
+		OpTypes:      []models.OpTypeShare{{OpType: OP, Percent: 50}, {OpType: OPC, Percent: 50}},
+		Slices:       []models.SliceShare{{Slice: sst1, Percent: 40}, {Slice: sst2, Percent: 60}},
+		SessionTypes: []models.SessionTypeShare{{Type: SESSION_TYPE_IPV6, Percent: 10}, {Type: SESSION_TYPE_IPV4, Percent: 90}},
+	}
+
+	profiles, err := NewOperator(config).GenerateUes(20)
+	if err != nil {
+		t.Fatalf("GenerateUes failed: %v", err)
+	}
+	counts := make(map[string]int)
+	for _, ue := range profiles {
+		counts[map[int]string{A_SCHEME: "A", B_SCHEME: "B"}[ue.ProtectionScheme]]++
+		counts[ue.OpType]++
+		if ue.UeDefaultNssai[0] != ue.Sessions[0].Slice {
+			t.Fatalf("session slice %+v differs from default slice %+v", ue.Sessions[0].Slice, ue.UeDefaultNssai[0])
+		}
+		if ue.UeDefaultNssai[0] == sst2 {
+			counts["sst2"]++
+		}
+		counts[ue.Sessions[0].Type]++
+	}
+	want := map[string]int{"A": 14, "B": 6, OP: 10, OPC: 10, "sst2": 12, SESSION_TYPE_IPV6: 2, SESSION_TYPE_IPV4: 18}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Fatalf("fleet counts = %v, want %v", counts, want)
+		}
+	}
+	if config.Sessions[0].Type != SESSION_TYPE_IPV4 || config.Sessions[0].Slice != sst1 {
+		t.Fatalf("configured sessions modified: %+v", config.Sessions)
+	}
+}
+
+func TestGenerateUesRejectsInvalidDistribution(t *testing.T) {
+	for name, dist := range map[string]*models.BatchDistribution{
+		"shares below 100%":   {Schemes: []models.SchemeShare{{Scheme: A_SCHEME, Percent: 50}}},
+		"partial UEs":         {Schemes: []models.SchemeShare{{Scheme: A_SCHEME, Percent: 70}, {Scheme: B_SCHEME, Percent: 30}}},
+		"TUAK OP type":        {OpTypes: []models.OpTypeShare{{OpType: TOPC, Percent: 100}}},
+		"unconfigured slice":  {Slices: []models.SliceShare{{Slice: models.Snssai{Sst: 3}, Percent: 100}}},
+		"no sessions":         {SessionTypes: []models.SessionTypeShare{{Type: SESSION_TYPE_IPV6, Percent: 100}}},
+		"unknown scheme":      {Schemes: []models.SchemeShare{{Scheme: 3, Percent: 100}}},
+		"unknown sessionType": {SessionTypes: []models.SessionTypeShare{{Type: "PPP", Percent: 100}}},
+	} {
+		config := seededConfig(1)
+		config.UeConfiguredNssai = []models.Snssai{{Sst: 1, Sd: "010203"}}
+		config.Distribution = dist
+		if _, err := NewOperator(config).GenerateUes(4); err == nil {
+			t.Errorf("GenerateUes accepted a distribution with %s", name)
+		}
+	}
+}
+
+func TestValidateDistribution(t *testing.T) {
+	config := seededConfig(23)
+	config.Seed = nil
+	if err := ValidateDistribution(config); err != nil {
+		t.Fatalf("ValidateDistribution without a distribution failed: %v", err)
+	}
+	// 70% of an unknown batch size is fine: the size is only checked when a batch is generated
+	config.Distribution = &models.BatchDistribution{Schemes: []models.SchemeShare{{Scheme: A_SCHEME, Percent: 70}, {Scheme: B_SCHEME, Percent: 30}}}
+	if err := ValidateDistribution(config); err != nil {
+		t.Fatalf("ValidateDistribution rejected a valid distribution: %v", err)
+	}
+
+	for name, dist := range map[string]*models.BatchDistribution{
+		"90% of schemes":      {Schemes: []models.SchemeShare{{Scheme: A_SCHEME, Percent: 90}}},
+		"unconfigured scheme": {Schemes: []models.SchemeShare{{Scheme: NULL_SCHEME, Percent: 50}, {Scheme: 3, Percent: 50}}},
+		"unknown session":     {SessionTypes: []models.SessionTypeShare{{Type: "PPP", Percent: 100}}},
+	} {
+		config.Distribution = dist
+		if err := ValidateDistribution(config); err == nil {
+			t.Errorf("ValidateDistribution accepted a distribution with %s", name)
+		}
+	}
+}
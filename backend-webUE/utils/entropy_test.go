@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"backend-webUE/models"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// Fixtures are generated from seeds, which only test mode accepts
+func TestMain(m *testing.M) {
+	os.Setenv(TestModeEnv, "true")
+	os.Exit(m.Run())
+}
+
+func TestSeededEntropySourceIsReproducible(t *testing.T) {
+	a, b, c := newSeededEntropySource(42), newSeededEntropySource(42), newSeededEntropySource(43)
+
+	bufA, bufB, bufC := make([]byte, 100), make([]byte, 100), make([]byte, 100)
+	io.ReadFull(a, bufA)
+	io.ReadFull(b, bufB)
+	io.ReadFull(c, bufC)
+	if !bytes.Equal(bufA, bufB) {
+		t.Fatal("sources with the same seed differ")
+	}
+	if bytes.Equal(bufA, bufC) {
+		t.Fatal("sources with different seeds are equal")
+	}
+}
+
+func TestRandomDigits(t *testing.T) {
+	digits, err := randomDigits(newSeededEntropySource(1), 1000)
+	if err != nil {
+		t.Fatalf("randomDigits failed: %v", err)
+	}
+	if len(digits) != 1000 {
+		t.Fatalf("got %d digits, want 1000", len(digits))
+	}
+	for _, d := range digits {
+		if d < '0' || d > '9' {
+			t.Fatalf("unexpected digit %q", d)
+		}
+	}
+}
+
+func TestGenerateUeWithSeededEntropy(t *testing.T) {
+	generate := func(seed int64) *models.UeProfile {
+		o := NewOperator(&OperatorConfig{
+			PlmnId: models.PlmnId{Mcc: "208", Mnc: "93"},
+			Profiles: []models.Profile{
+				{Scheme: A_SCHEME, PublicKey: "5a8d38864820197c3394b92613b20b91633cbd897119273bf8e4a6f4eec0a650"},
+				{Scheme: B_SCHEME, PublicKey: "0272da71976234ce833a6907425867b82e074d44ef907dfb4b3e21c1c2256ebcd1"},
+			},
+			Entropy: newSeededEntropySource(seed),
+		})
+		ue, err := o.GenerateUe()
+		if err != nil {
+			t.Fatalf("GenerateUe failed: %v", err)
+		}
+		return ue
+	}
+
+	first, second := generate(7), generate(7)
+	if first.Supi != second.Supi || first.Suci != second.Suci || first.Key != second.Key ||
+		first.Op != second.Op || first.OpType != second.OpType || first.Imei != second.Imei {
+		t.Fatalf("seeded UE Profiles differ:\n%+v\n%+v", first, second)
+	}
+	if len(first.Key) != 32 || len(first.Op) != 32 {
+		t.Fatalf("K and OP must be 128 bits, got %q and %q", first.Key, first.Op)
+	}
+	if other := generate(8); other.Key == first.Key {
+		t.Fatal("different seeds generated the same K")
+	}
+}
+
+func TestGenerateUeWithDefaultEntropy(t *testing.T) {
+	o := NewOperator(&OperatorConfig{PlmnId: models.PlmnId{Mcc: "208", Mnc: "93"}})
+	first, err := o.GenerateUe()
+	if err != nil {
+		t.Fatalf("GenerateUe failed: %v", err)
+	}
+	second, err := o.GenerateUe()
+	if err != nil {
+		t.Fatalf("GenerateUe failed: %v", err)
+	}
+	if first.Key == second.Key || first.Op == second.Op {
+		t.Fatal("crypto/rand generated the same credentials twice")
+	}
+}
+
+func TestSeedNeedsTestMode(t *testing.T) {
+	t.Setenv(TestModeEnv, "")
+	if _, err := NewOperator(seededConfig(16)).GenerateUe(); !errors.Is(err, ErrSeedOutsideTestMode) {
+		t.Fatalf("GenerateUe with a seed outside test mode = %v, want ErrSeedOutsideTestMode", err)
+	}
+	if _, err := NewOperator(seededConfig(16)).GenerateUes(2); !errors.Is(err, ErrSeedOutsideTestMode) {
+		t.Fatalf("GenerateUes with a seed outside test mode = %v, want ErrSeedOutsideTestMode", err)
+	}
+	config := seededConfig(16)
+	config.Seed = nil
+	if _, err := NewOperator(config).GenerateUes(2); err != nil {
+		t.Fatalf("GenerateUes without a seed outside test mode failed: %v", err)
+	}
+
+	t.Setenv(TestModeEnv, "true")
+	if _, err := NewOperator(seededConfig(16)).GenerateUes(2); err != nil {
+		t.Fatalf("GenerateUes with a seed in test mode failed: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+// utils/imei.go
+package utils
+
+import (
+	"fmt"
+)
+
+// IMEI and IMEISV structure (TS 23.003 clause 6.2): IMEI = TAC || SNR || CD, IMEISV = TAC || SNR || SVN
+const (
+	TacLen    = 8  // digits, Type Allocation Code
+	SnrLen    = 6  // digits, serial number
+	SvnLen    = 2  // digits, software version number
+	ImeiLen   = 15 // digits
+	ImeisvLen = 16 // digits
+
+	// DEFAULT_SVN is the software version number of generated IMEISVs; 99 is reserved
+	DEFAULT_SVN = "01"
+)
+
+// LuhnCheckDigit computes the IMEI check digit over TAC || SNR (TS 23.003 Annex B)
+func LuhnCheckDigit(digits string) (byte, error) {
+	if err := checkDigits(digits, TacLen+SnrLen); err != nil {
+		return 0, err
+	}
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[i] - '0')
+		// Every second digit from the left is doubled and its digits summed
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// ValidateImei checks the length, digits and Luhn check digit of an IMEI
+func ValidateImei(imei string) error {
+	if err := checkDigits(imei, ImeiLen); err != nil {
+		return fmt.Errorf("invalid IMEI: %v", err)
+	}
+	cd, _ := LuhnCheckDigit(imei[:TacLen+SnrLen])
+	if imei[ImeiLen-1] != cd {
+		return fmt.Errorf("invalid IMEI %s: check digit must be %c", imei, cd)
+	}
+	return nil
+}
+
+// ValidateImeisv checks the length, digits and software version number of an IMEISV
+func ValidateImeisv(imeisv string) error {
+	if err := checkDigits(imeisv, ImeisvLen); err != nil {
+		return fmt.Errorf("invalid IMEISV: %v", err)
+	}
+	return ValidateSvn(imeisv[TacLen+SnrLen:])
+}
+
+// ValidateSvn checks a software version number; 99 is reserved for future use
+func ValidateSvn(svn string) error {
+	if err := checkDigits(svn, SvnLen); err != nil {
+		return fmt.Errorf("invalid SVN: %v", err)
+	}
+	if svn == "99" {
+		return fmt.Errorf("invalid SVN: 99 is reserved")
+	}
+	return nil
+}
+
+// ValidateTac checks a Type Allocation Code
+func ValidateTac(tac string) error {
+	if err := checkDigits(tac, TacLen); err != nil {
+		return fmt.Errorf("invalid TAC: %v", err)
+	}
+	return nil
+}
+
+// ValidateUeImei checks the IMEI and IMEISV of a UE Profile. Both are optional, but when both are
+// set they must belong to the same equipment, TAC || SNR.
+func ValidateUeImei(imei, imeisv string) error {
+	if imei != "" {
+		if err := ValidateImei(imei); err != nil {
+			return err
+		}
+	}
+	if imeisv != "" {
+		if err := ValidateImeisv(imeisv); err != nil {
+			return err
+		}
+	}
+	if imei != "" && imeisv != "" && imei[:TacLen+SnrLen] != imeisv[:TacLen+SnrLen] {
+		return fmt.Errorf("IMEI %s and IMEISV %s have different TAC and serial numbers", imei, imeisv)
+	}
+	return nil
+}
+
+// checkDigits checks that s has exactly n decimal digits
+func checkDigits(s string, n int) error {
+	if len(s) != n {
+		return fmt.Errorf("%q must have %d digits", s, n)
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return fmt.Errorf("%q must only have digits", s)
+		}
+	}
+	return nil
+}
+
+// randImei generates an IMEI and the IMEISV of the same equipment from a TAC of the
+// operator's pool, a random serial number and the configured SVN
+func (o *Operator) randImei() (string, string, error) {
+	var tac string
+	var err error
+	if len(o.config.Tacs) > 0 {
+		index, err := randomIntn(o.entropy(), len(o.config.Tacs))
+		if err != nil {
+			return "", "", err
+		}
+		tac = o.config.Tacs[index]
+	} else if tac, err = randomDigits(o.entropy(), TacLen); err != nil {
+		return "", "", err
+	}
+	snr, err := randomDigits(o.entropy(), SnrLen)
+	if err != nil {
+		return "", "", err
+	}
+	svn := o.config.Svn
+	if svn == "" {
+		svn = DEFAULT_SVN
+	}
+
+	cd, err := LuhnCheckDigit(tac + snr)
+	if err != nil {
+		return "", "", err
+	}
+	return tac + snr + string(cd), tac + snr + svn, nil
+}
+
+// validateImeiConfig checks the operator's TAC pool and SVN
+func (o *Operator) validateImeiConfig() error {
+	for _, tac := range o.config.Tacs {
+		if err := ValidateTac(tac); err != nil {
+			return err
+		}
+	}
+	if o.config.Svn != "" {
+		return ValidateSvn(o.config.Svn)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"backend-webUE/models"
+	"testing"
+)
+
+func TestLuhnCheckDigit(t *testing.T) {
+	cases := map[string]byte{
+		"35145120840121": '6', // TS 23.003 Annex B example
+		"49015420323751": '8',
+	}
+	for digits, want := range cases {
+		cd, err := LuhnCheckDigit(digits)
+		if err != nil {
+			t.Fatalf("LuhnCheckDigit failed: %v", err)
+		}
+		if cd != want {
+			t.Fatalf("LuhnCheckDigit(%s) = %c, want %c", digits, cd, want)
+		}
+	}
+	if _, err := LuhnCheckDigit("3514512084012"); err == nil {
+		t.Fatal("LuhnCheckDigit accepted 13 digits")
+	}
+}
+
+func TestValidateUeImei(t *testing.T) {
+	cases := []struct {
+		imei, imeisv string
+		valid        bool
+	}{
+		{"351451208401216", "3514512084012101", true},
+		{"", "", true},
+		{"351451208401215", "", false},                 // wrong check digit
+		{"35145120840121", "", false},                  // too short
+		{"35145120840121a", "", false},                 // not a digit
+		{"", "3514512084012199", false},                // reserved SVN
+		{"351451208401216", "3514512084012201", false}, // different serial number
+	}
+	for _, c := range cases {
+		err := ValidateUeImei(c.imei, c.imeisv)
+		if (err == nil) != c.valid {
+			t.Errorf("ValidateUeImei(%q, %q) = %v, want valid %v", c.imei, c.imeisv, err, c.valid)
+		}
+	}
+}
+
+func TestGenerateUeImei(t *testing.T) {
+	tacs := []string{"35145120", "86091203"}
+	o := NewOperator(&OperatorConfig{PlmnId: models.PlmnId{Mcc: "208", Mnc: "93"}, Tacs: tacs, Svn: "07"})
+	for i := 0; i < 20; i++ {
+		ue, err := o.GenerateUe()
+		if err != nil {
+			t.Fatalf("GenerateUe failed: %v", err)
+		}
+		if err := ValidateUeImei(ue.Imei, ue.Imeisv); err != nil {
+			t.Fatalf("generated IMEI is invalid: %v", err)
+		}
+		if tac := ue.Imei[:TacLen]; tac != tacs[0] && tac != tacs[1] {
+			t.Fatalf("TAC %s is not in the pool", tac)
+		}
+		if svn := ue.Imeisv[TacLen+SnrLen:]; svn != "07" {
+			t.Fatalf("SVN = %s, want 07", svn)
+		}
+	}
+
+	o = NewOperator(&OperatorConfig{PlmnId: models.PlmnId{Mcc: "208", Mnc: "93"}, Svn: "99"})
+	if _, err := o.GenerateUe(); err == nil {
+		t.Fatal("GenerateUe accepted the reserved SVN 99")
+	}
+}
@@ -3,13 +3,13 @@ package utils
 
 import (
 	"backend-webUE/models"
+	"backend-webUE/plmn"
 	"backend-webUE/supi-key" // Ensure this import is correct
-	"crypto/md5"
+	"backend-webUE/tuak"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
-	"math/rand"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -19,45 +19,31 @@ const (
 	IMSI_TYPE   = 0
 	NAI_TYPE    = 1
 	SUCI_PREFIX = "suci"
+	IMSI_PREFIX = "imsi"
+	NAI_PREFIX  = "nai"
 
 	OPC = "OPC"
 	OP  = "OP"
+	// TOP and TOPC are the operator variants of TUAK (TS 35.231)
+	TOPC = "TOPC"
+	TOP  = "TOP"
+
+	MILENAGE = "MILENAGE"
+	TUAK     = "TUAK"
 
 	NULL_SCHEME = 0
 	A_SCHEME    = 1
 	B_SCHEME    = 2
-)
-
-// Initialize random seed
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
-var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
-
-// Helper Functions
-func randSeq(n int) string {
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
-	}
-	return string(b)
-}
-
-func md5Hash(text string) string {
-	hash := md5.Sum([]byte(text))
-	return hex.EncodeToString(hash[:])
-}
 
-func generateRandomMsisdn(length int) string {
-	const digits = "0123456789"
+	// Profile A and B keys are registered under home network public key IDs 1 to 255;
+	// the null scheme carries ID 0
+	MinHomeNetworkKeyId = 1
+	MaxHomeNetworkKeyId = 255
 
-	msisdn := make([]byte, length)
-	for i := 0; i < length; i++ {
-		msisdn[i] = digits[rand.Intn(len(digits))]
-	}
-	return string(msisdn)
-}
+	DEFAULT_ROUTING_INDICATOR = "0000"
+	// DEFAULT_AMF has the AMF separation bit set, as 5G-AKA requires
+	DEFAULT_AMF = "8000"
+)
 
 // OperatorConfig holds configuration for the Operator
 type OperatorConfig struct {
@@ -67,50 +53,165 @@ type OperatorConfig struct {
 	UeDefaultNssai    []models.Snssai
 	Profiles          []models.Profile
 	GnbSearchList     []string
-	Sessions          []models.Sessions
-	UacAic            models.UacAic
-	UacAcc            models.UacAcc
-	Integrity         models.Integrity
-	Ciphering         models.Ciphering
-	IntegrityMaxRate  models.IntegrityMaxRate
+	// Sessions are the PDU sessions of every UE, each with a session type, a DNN (Apn) and an
+	// S-NSSAI of UeConfiguredNssai. SDs are stored as 6 lowercase hex digits.
+	Sessions         []models.Sessions
+	UacAic           models.UacAic
+	UacAcc           models.UacAcc
+	Integrity        models.Integrity
+	Ciphering        models.Ciphering
+	IntegrityMaxRate models.IntegrityMaxRate
+	// SupiType selects IMSI_TYPE or NAI_TYPE SUPIs; NaiRealm defaults to the PLMN's 5GC realm
+	SupiType int
+	NaiRealm string
+	// SupiAllocation is SUPI_ALLOC_RANDOM (default), SUPI_ALLOC_SEQUENTIAL from MsinStart or
+	// SUPI_ALLOC_RANGE from MsinStart to MsinEnd, both inclusive
+	SupiAllocation string
+	MsinStart      string
+	MsinEnd        string
+	// SupiRegistry, when set, reports the SUPIs already provisioned. They are skipped, or
+	// returned as a SupiCollisionError when ReportSupiCollisions is set.
+	SupiRegistry         SupiRegistry
+	ReportSupiCollisions bool
+	// Tacs is the pool of Type Allocation Codes of generated IMEIs, random TACs when empty;
+	// Svn is the software version number of generated IMEISVs, DEFAULT_SVN when empty
+	Tacs []string
+	Svn  string
+	// Distribution, when set, shares a batch out in exact proportions of protection schemes,
+	// OP types, slices and session types instead of random or fixed choices
+	Distribution *models.BatchDistribution
+	// AuthAlgorithm is MILENAGE (default) or TUAK
+	AuthAlgorithm string
+	// KeySource, when set, supplies the home network keys instead of Profiles; Profiles then only select the schemes
+	KeySource HomeNetworkKeySource
+	// Entropy supplies K, OP, ephemeral keys and identifiers; it defaults to DefaultEntropySource
+	Entropy io.Reader
+	// Seed, when set, replaces Entropy with a stream derived from the seed, so the same seed and
+	// configuration always generate the same fleet, credentials included. Anyone who knows the seed
+	// can recreate its K and OP, so outside test mode generation fails with ErrSeedOutsideTestMode.
+	Seed *int64
+}
+
+// HomeNetworkKeySource resolves the active home network key of a protection scheme
+type HomeNetworkKeySource interface {
+	ActiveKey(scheme int) (*models.HomeNetworkKey, error)
 }
 
 // Operator represents the operator responsible for UE profile management
 type Operator struct {
 	config *OperatorConfig
+	// seeded is the entropy stream of a seeded configuration, shared by all UEs it generates
+	seeded io.Reader
+	// supis is the SUPI allocation state
+	supis supiAllocator
 }
 
 // NewOperator creates a new Operator
 func NewOperator(config *OperatorConfig) *Operator {
-	return &Operator{
+	o := &Operator{
 		config: config,
 	}
+	if config.Seed != nil {
+		o.seeded = newSeededEntropySource(*config.Seed)
+	}
+	return o
+}
+
+// entropy returns the operator's entropy source
+func (o *Operator) entropy() io.Reader {
+	if o.seeded != nil {
+		return o.seeded
+	}
+	if o.config.Entropy != nil {
+		return o.config.Entropy
+	}
+	return DefaultEntropySource
 }
 
 // GenerateUe generates a new UE Profile and returns it along with any error encountered
 func (o *Operator) GenerateUe() (*models.UeProfile, error) {
-	// Check if Profiles slice is not empty
-	if len(o.config.Profiles) == 0 {
-		log.Println("Operator configuration has no Profiles defined")
-		return nil, fmt.Errorf("operator configuration has no profiles defined")
+	if err := o.checkSeed(); err != nil {
+		return nil, err
+	}
+	return o.generateUe(nil, 0)
+}
+
+// checkSeed refuses to generate from a seed outside test mode
+func (o *Operator) checkSeed() error {
+	if o.seeded != nil && !TestMode() {
+		return ErrSeedOutsideTestMode
+	}
+	return nil
+}
+
+// generateUe generates the i-th UE Profile of a batch with the shares the plan assigned to it;
+// without a plan the protection scheme and OP type are drawn at random
+func (o *Operator) generateUe(plan *batchPlan, i int) (*models.UeProfile, error) {
+	if err := ValidateAuthAlgorithm(o.config.AuthAlgorithm); err != nil {
+		return nil, err
+	}
+	if err := o.validateImeiConfig(); err != nil {
+		return nil, err
 	}
 
 	// Generate SUPI and SUCI
-	supi := o.randSupi()
-	if supi == "" {
-		return nil, fmt.Errorf("failed to generate SUPI")
+	supi, err := o.allocateSupi()
+	if err != nil {
+		log.Printf("Error generating SUPI: %v\n", err)
+		return nil, err
 	}
 
-	// Select a random profile from Profiles slice
-	selectedProfile := o.config.Profiles[rand.Intn(len(o.config.Profiles))]
+	// Select a random profile from Profiles slice, or the null scheme when no home network key is configured
+	selectedProfile := models.Profile{Scheme: NULL_SCHEME}
+	if plan != nil && plan.schemes != nil {
+		if selectedProfile, err = o.schemeProfile(o.config.Distribution.Schemes[plan.schemes[i]].Scheme); err != nil {
+			return nil, err
+		}
+	} else if len(o.config.Profiles) > 0 {
+		index, err := randomIntn(o.entropy(), len(o.config.Profiles))
+		if err != nil {
+			return nil, err
+		}
+		selectedProfile = o.config.Profiles[index]
+	}
 
-	// Sử dụng selectedProfile.Scheme làm tham số thứ hai cho toSuci
-	suci, err := o.toSuci(supi, selectedProfile.Scheme)
+	// With a key registry the UE references the active key of the scheme by its key ID;
+	// inline profiles are registered under their scheme number, and the null scheme under 0
+	keyId := selectedProfile.Scheme
+	if o.config.KeySource != nil && selectedProfile.Scheme != NULL_SCHEME {
+		key, err := o.config.KeySource.ActiveKey(selectedProfile.Scheme)
+		if err != nil {
+			log.Printf("Error resolving home network key: %v\n", err)
+			return nil, err
+		}
+		selectedProfile = models.Profile{Scheme: key.Scheme, PublicKey: key.PublicKey}
+		keyId = key.KeyId
+	}
+	if err := ValidateProfile(selectedProfile); err != nil {
+		log.Printf("Invalid profile: %v\n", err)
+		return nil, err
+	}
+
+	// Conceal the SUPI with the selected profile and the key ID it is registered under
+	suci, err := o.toSuci(supi, selectedProfile, keyId)
 	if err != nil {
 		log.Printf("Error generating SUCI: %v\n", err)
 		return nil, err
 	}
 
+	key, err := o.randUeKey()
+	if err != nil {
+		return nil, err
+	}
+	op, err := o.randOp()
+	if err != nil {
+		return nil, err
+	}
+	imei, imeisv, err := o.randImei()
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate UE Profile
 	ueProfile := &models.UeProfile{
 		Supi:                   supi,
@@ -118,17 +219,16 @@ func (o *Operator) GenerateUe() (*models.UeProfile, error) {
 		PlmnId:                 o.config.PlmnId,
 		UeConfiguredNssai:      o.config.UeConfiguredNssai,
 		UeDefaultNssai:         o.config.UeDefaultNssai,
-		RoutingIndicator:       "0000",
-		HomeNetworkPrivateKey:  selectedProfile.PrivateKey,
+		RoutingIndicator:       DEFAULT_ROUTING_INDICATOR,
 		HomeNetworkPublicKey:   selectedProfile.PublicKey,
-		HomeNetworkPublicKeyId: selectedProfile.Scheme,
+		HomeNetworkPublicKeyId: keyId,
 		ProtectionScheme:       selectedProfile.Scheme,
-		Key:                    o.randUeKey(),
-		Op:                     o.randOp(),
+		Key:                    key,
+		Op:                     op,
 		OpType:                 OP,
 		Amf:                    o.config.Amf,
-		Imei:                   o.randImei(),
-		Imeisv:                 o.randImeiSv(),
+		Imei:                   imei,
+		Imeisv:                 imeisv,
 		GnbSearchList:          o.config.GnbSearchList,
 		Integrity:              o.config.Integrity,
 		Ciphering:              o.config.Ciphering,
@@ -139,148 +239,377 @@ func (o *Operator) GenerateUe() (*models.UeProfile, error) {
 		CreatedAt:              time.Now(),
 	}
 
-	// Assign OP or OPC based on random value
-	value := rand.Intn(2)
+	// Assign OP or OPC from the plan, or based on random value
+	var value int
+	if plan != nil && plan.opTypes != nil {
+		if isOpc, _ := o.isOpc(o.config.Distribution.OpTypes[plan.opTypes[i]].OpType); isOpc {
+			value = 1
+		}
+	} else if value, err = randomIntn(o.entropy(), 2); err != nil {
+		return nil, err
+	}
 	if value == 0 {
 		ueProfile.OpType = OP
 	} else {
 		ueProfile.OpType = OPC
 	}
 
-	// Call GenProfile to set the protection scheme and public key based on profile index
-	err = GenProfile(ueProfile, selectedProfile.Scheme, o.config.Profiles)
+	// TUAK profiles carry TOP, or the TOPc derived from it
+	if o.config.AuthAlgorithm == TUAK {
+		if ueProfile.Op, err = o.randTop(); err != nil {
+			return nil, err
+		}
+		ueProfile.OpType = TOP
+		if value == 1 {
+			topc, err := tuak.GenerateTopcHex(ueProfile.Key, ueProfile.Op)
+			if err != nil {
+				log.Printf("Error deriving TOPc: %v\n", err)
+				return nil, err
+			}
+			ueProfile.Op = topc
+			ueProfile.OpType = TOPC
+		}
+	}
+
+	// Call GenProfile to set the protection scheme, public key and key ID of the selected profile
+	err = GenProfile(ueProfile, selectedProfile.Scheme, keyId, []models.Profile{selectedProfile})
 	if err != nil {
 		log.Printf("Error in GenProfile: %v\n", err)
 		return nil, err
 	}
 
+	if plan != nil && plan.slices != nil {
+		applySlice(ueProfile, o.config.Distribution.Slices[plan.slices[i]].Slice)
+	}
+	if plan != nil && plan.sessionTypes != nil {
+		applySessionType(ueProfile, o.config.Distribution.SessionTypes[plan.sessionTypes[i]].Type)
+	}
+	if err := NormalizeUeSessions(ueProfile); err != nil {
+		return nil, err
+	}
+
 	return ueProfile, nil
 }
 
-// GenProfile sets the protection scheme and public key based on profile index
-func GenProfile(ue *models.UeProfile, profileScheme int, profiles []models.Profile) error {
+// GenerateUes generates a batch of UE Profiles. With a seeded configuration the batch is
+// reproducible: the UEs are generated in order from a single stream. With a distribution the
+// batch holds each share in its apportioned number of UEs.
+func (o *Operator) GenerateUes(count int) ([]models.UeProfile, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid number of UE Profiles: %d", count)
+	}
+	if err := o.checkSeed(); err != nil {
+		return nil, err
+	}
+	plan, err := o.planBatch(count)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]models.UeProfile, 0, count)
+	for i := 0; i < count; i++ {
+		ue, err := o.generateUe(plan, i)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *ue)
+	}
+	return profiles, nil
+}
+
+// GenProfile sets the protection scheme and the public key of the scheme's profile, registered
+// under the home network public key ID keyId
+func GenProfile(ue *models.UeProfile, profileScheme int, keyId int, profiles []models.Profile) error {
+	if err := ValidateHomeNetworkKeyId(profileScheme, keyId); err != nil {
+		return err
+	}
 	switch profileScheme {
+	case NULL_SCHEME:
+		// The null scheme sends the MSIN in clear, so the UE carries no home network key
+		ue.ProtectionScheme = NULL_SCHEME
+		ue.HomeNetworkPublicKey = ""
+	case A_SCHEME, B_SCHEME:
+		profile, ok := findProfile(profiles, profileScheme)
+		if !ok {
+			return fmt.Errorf("no profile configured for scheme: %d", profileScheme)
+		}
+		ue.ProtectionScheme = profileScheme
+		ue.HomeNetworkPublicKey = profile.PublicKey
+	default:
+		return fmt.Errorf("unsupported profile scheme: %d", profileScheme)
+	}
+	ue.HomeNetworkPublicKeyId = keyId
+	ue.RoutingIndicator = DEFAULT_ROUTING_INDICATOR
+	return nil
+}
+
+// ValidateHomeNetworkKeyId checks the home network public key ID of a protection scheme:
+// 0 for the null scheme, MinHomeNetworkKeyId to MaxHomeNetworkKeyId for Profile A and B
+func ValidateHomeNetworkKeyId(scheme int, keyId int) error {
+	if scheme == NULL_SCHEME {
+		if keyId != 0 {
+			return fmt.Errorf("null scheme requires home network public key ID 0, got %d", keyId)
+		}
+		return nil
+	}
+	if keyId < MinHomeNetworkKeyId || keyId > MaxHomeNetworkKeyId {
+		return fmt.Errorf("home network public key ID out of range: %d", keyId)
+	}
+	return nil
+}
+
+// findProfile returns the first profile configured for the given scheme
+func findProfile(profiles []models.Profile, scheme int) (models.Profile, bool) {
+	for _, profile := range profiles {
+		if profile.Scheme == scheme {
+			return profile, true
+		}
+	}
+	return models.Profile{}, false
+}
+
+// ValidateProfile checks that a profile carries the home network public key its scheme needs.
+// The null scheme needs no key.
+func ValidateProfile(profile models.Profile) error {
+	var keyLen int
+	switch profile.Scheme {
+	case NULL_SCHEME:
+		return nil
 	case A_SCHEME:
-		ue.ProtectionScheme = A_SCHEME
-		ue.HomeNetworkPublicKey = profiles[0].PublicKey
-		ue.HomeNetworkPublicKeyId = A_SCHEME
+		keyLen = supi.ProfileAPubKeyLen
 	case B_SCHEME:
-		ue.ProtectionScheme = B_SCHEME
-		ue.HomeNetworkPublicKey = profiles[1].PublicKey
-		ue.HomeNetworkPublicKeyId = B_SCHEME
+		keyLen = supi.ProfileBPubKeyLen
 	default:
-		return fmt.Errorf("unsupported profile scheme: %d", profileScheme)
+		return fmt.Errorf("unsupported profile scheme: %d", profile.Scheme)
+	}
+
+	if profile.PublicKey == "" {
+		return fmt.Errorf("profile scheme %d requires a home network public key", profile.Scheme)
+	}
+	pubKey, err := hex.DecodeString(profile.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid home network public key: %v", err)
+	}
+	if len(pubKey) != keyLen {
+		return fmt.Errorf("invalid home network public key length for scheme %d: %d octets", profile.Scheme, len(pubKey))
 	}
-	ue.RoutingIndicator = "0000"
 	return nil
 }
 
-// randUeKey generates a random UE Key
-func (o *Operator) randUeKey() string {
-	return md5Hash(randSeq(16))
+// randUeKey generates a random 128-bit UE Key
+func (o *Operator) randUeKey() (string, error) {
+	return randomHex(o.entropy(), 16)
+}
+
+// randOp generates a random 128-bit OP string
+func (o *Operator) randOp() (string, error) {
+	return randomHex(o.entropy(), 16)
 }
 
-// randOp generates a random OP string
-func (o *Operator) randOp() string {
-	return md5Hash(randSeq(16))
+// randTop generates a random 256-bit TUAK TOP string
+func (o *Operator) randTop() (string, error) {
+	return randomHex(o.entropy(), 32)
+}
+
+// AuthAlgorithm returns the authentication algorithm a UE Profile's OP type belongs to
+func AuthAlgorithm(ue *models.UeProfile) string {
+	switch ue.OpType {
+	case TOP, TOPC:
+		return TUAK
+	default:
+		return MILENAGE
+	}
+}
+
+// ValidateAuthAlgorithm checks an operator's authentication algorithm setting
+func ValidateAuthAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", MILENAGE, TUAK:
+		return nil
+	default:
+		return fmt.Errorf("unsupported authentication algorithm %q", algorithm)
+	}
 }
 
 // randSupi generates a random SUPI (e.g., IMSI)
-func (o *Operator) randSupi() string {
-	mcc := o.config.PlmnId.Mcc
-	mnc := o.config.PlmnId.Mnc
-	mcclen := len(mcc)
-	mnclen := len(mnc)
-	msinlen := 15 - mcclen - mnclen
-	if msinlen <= 0 {
-		log.Println("Invalid PLMN ID lengths leading to non-positive MSIN length")
-		return ""
-	}
-	msisdn := generateRandomMsisdn(msinlen)
-	return "imsi-" + mcc + mnc + msisdn
+func (o *Operator) randSupi() (string, error) {
+	n, err := o.supiDigits()
+	if err != nil {
+		return "", err
+	}
+	digits, err := randomDigits(o.entropy(), n)
+	if err != nil {
+		return "", err
+	}
+	return o.formatSupi(digits)
 }
 
-func (o *Operator) toSuci(supii string, profile int) (string, error) {
-	// Check if profile index is valid
-	if profile <= 0 || profile > len(o.config.Profiles) {
-		return "", fmt.Errorf("invalid profile index: %d", profile)
+// supiDigits returns the number of digits that tell the operator's SUPIs apart:
+// the MSIN of an IMSI or the username digits of a NAI
+func (o *Operator) supiDigits() (int, error) {
+	if o.config.SupiType == NAI_TYPE {
+		return naiUsernameDigits, nil
 	}
+	p, err := PlmnOf(o.config.PlmnId)
+	if err != nil {
+		return 0, err
+	}
+	return p.MsinLen(), nil
+}
+
+// formatSupi builds the SUPI of an MSIN, or the NAI-type SUPI nai-ue<digits>@<realm>
+func (o *Operator) formatSupi(digits string) (string, error) {
+	realm := o.config.NaiRealm
+	if o.config.SupiType != NAI_TYPE || realm == "" {
+		p, err := PlmnOf(o.config.PlmnId)
+		if err != nil {
+			return "", err
+		}
+		if o.config.SupiType != NAI_TYPE {
+			return IMSI_PREFIX + "-" + p.Digits() + digits, nil
+		}
+		realm = p.NaiRealm()
+	}
+	return NAI_PREFIX + "-ue" + digits + "@" + realm, nil
+}
+
+func (o *Operator) toSuci(supii string, profile models.Profile, keyId int) (string, error) {
+	if profile.Scheme == NULL_SCHEME {
+		return concealSupi(o.entropy(), supii, o.config.PlmnId, DEFAULT_ROUTING_INDICATOR, NULL_SCHEME, 0, "")
+	}
+	return concealSupi(o.entropy(), supii, o.config.PlmnId, DEFAULT_ROUTING_INDICATOR, profile.Scheme, keyId, profile.PublicKey)
+}
 
-	// Extract profile information
+// ConcealSupi computes the SUCI of an IMSI- or NAI-type SUPI with the given home network public key.
+// An empty PLMN ID takes the MCC and MNC of an IMSI from the MCC/MNC table.
+func ConcealSupi(supii string, plmnId models.PlmnId, routingIndicator string, scheme int, keyId int, hnPubKey string) (string, error) {
+	return concealSupi(DefaultEntropySource, supii, plmnId, routingIndicator, scheme, keyId, hnPubKey)
+}
+
+// concealSupi computes a SUCI with an ephemeral key drawn from the given entropy source
+func concealSupi(entropy io.Reader, supii string, plmnId models.PlmnId, routingIndicator string, scheme int, keyId int, hnPubKey string) (string, error) {
 	var profileText string
-	var hnPubKey string
-	if profile == A_SCHEME {
+	switch scheme {
+	case NULL_SCHEME:
+	case A_SCHEME:
 		profileText = "A"
-		hnPubKey = o.config.Profiles[0].PublicKey
-	} else if profile == B_SCHEME {
+	case B_SCHEME:
 		profileText = "B"
-		hnPubKey = o.config.Profiles[1].PublicKey // Sửa từ Profiles[0] sang Profiles[1]
-	} else {
-		return "", fmt.Errorf("unsupported profile: %d", profile)
+	default:
+		return "", fmt.Errorf("unsupported profile: %d", scheme)
+	}
+	if err := ValidateHomeNetworkKeyId(scheme, keyId); err != nil {
+		return "", err
 	}
-
-	// Create key pair
-	a := supi.NewX25519("")
-	ephprivKey := hex.EncodeToString(a.GetPrivKey())
 
 	// Check SUPI format
-	parts := strings.Split(supii, "-")
+	parts := strings.SplitN(supii, "-", 2)
 	if len(parts) < 2 {
 		return "", fmt.Errorf("invalid SUPI format: %s", supii)
 	}
 	prefix := parts[0]
 
+	suci := &supi.Suci{
+		RoutingIndicator:       routingIndicator,
+		ProtectionSchemeId:     scheme,
+		HomeNetworkPublicKeyId: keyId,
+	}
+	// schemeInput is the MSIN of an IMSI or the username of a NAI
+	var schemeInput string
+
 	// Process the prefix of SUPI
-	if prefix == "suci" {
+	switch prefix {
+	case SUCI_PREFIX:
 		return supii, nil
-	} else if prefix != "imsi" {
+	case IMSI_PREFIX:
+		// Split the IMSI with the configured PLMN, or with the MNC length table without one
+		p, msin, err := SplitImsiSupi(supii, plmnId)
+		if err != nil {
+			return "", err
+		}
+		suci.SupiType = IMSI_TYPE
+		suci.Mcc = p.Mcc
+		suci.Mnc = p.Mnc
+		schemeInput = msin
+	case NAI_PREFIX:
+		// Only the username is concealed, the realm routes the SUCI to the home network
+		at := strings.LastIndex(parts[1], "@")
+		if at <= 0 || at == len(parts[1])-1 {
+			return "", fmt.Errorf("invalid NAI SUPI: %s", supii)
+		}
+		suci.SupiType = NAI_TYPE
+		suci.HomeNetworkIdentifier = parts[1][at+1:]
+		schemeInput = parts[1][:at]
+	default:
 		return "", fmt.Errorf("unsupported SUPI prefix: %s", prefix)
 	}
 
-	// Check PLMN configuration
-	if len(o.config.PlmnId.Mcc) == 0 || len(o.config.PlmnId.Mnc) == 0 {
-		return "", fmt.Errorf("missing PLMN ID configuration")
+	// The null scheme output is the MSIN or username in clear
+	schemeOutput := schemeInput
+	if scheme != NULL_SCHEME {
+		// Create an ephemeral key pair on the curve of the selected profile
+		a, err := supi.NewEphemeralKeyFrom(profileText, entropy)
+		if err != nil {
+			log.Printf("Error generating ephemeral key: %v\n", err)
+			return "", err
+		}
+		ephprivKey := hex.EncodeToString(a.GetPrivKey())
+
+		// SUCI formation
+		if suci.SupiType == NAI_TYPE {
+			schemeOutput, err = supi.Nai2Suci(profileText, hnPubKey, ephprivKey, schemeInput)
+		} else {
+			schemeOutput, err = supi.Supi2Suci(profileText, hnPubKey, ephprivKey, schemeInput)
+		}
+		if err != nil {
+			log.Printf("Error generating SUCI: %v\n", err)
+			return "", err
+		}
 	}
 
-	// Extract MCC and MNC
-	mcclen := len(o.config.PlmnId.Mcc)
-	mnclen := len(o.config.PlmnId.Mnc)
-	if len(parts[1]) < mcclen+mnclen {
-		return "", fmt.Errorf("invalid SUPI structure: %s", supii)
+	if err := suci.SetSchemeOutput(schemeOutput); err != nil {
+		return "", err
 	}
-	mnc := parts[1][:mnclen]
-	mcc := parts[1][mnclen : mnclen+mcclen]
-	msin := parts[1][mnclen+mcclen:]
-
-	// SUCI formation
-	suciTypeStr := strconv.Itoa(IMSI_TYPE)
-	schemeOutput, err := supi.Supi2Suci(profileText, hnPubKey, ephprivKey, msin)
-	if err != nil {
-		log.Printf("Error generating SUCI: %v\n", err)
+	// Validate the realm and the other header fields
+	if _, err := supi.ParseSuci(suci.String()); err != nil {
 		return "", err
 	}
-	routingIndicator := 0
-	routingIndicatorStr := strconv.Itoa(routingIndicator)
-	profileStr := strconv.Itoa(profile)
-
-	suci := strings.Join([]string{
-		SUCI_PREFIX, suciTypeStr, mnc, mcc, routingIndicatorStr, profileStr, profileStr, hnPubKey, schemeOutput,
-	}, "-")
-	return suci, nil
+	return suci.String(), nil
 }
 
-// getKeyPair generates and returns a key pair (dummy implementation)
-func (o *Operator) getKeyPair() (x supi.X25519) {
-	x.GenerateKeyPair()
-	return
+// PlmnOf validates a PLMN ID and returns it as a PLMN that knows its MNC length
+func PlmnOf(plmnId models.PlmnId) (plmn.Plmn, error) {
+	if plmnId.Mcc == "" || plmnId.Mnc == "" {
+		return plmn.Plmn{}, fmt.Errorf("missing PLMN ID configuration")
+	}
+	return plmn.New(plmnId.Mcc, plmnId.Mnc)
 }
 
-// randImei generates a random IMEI
-func (o *Operator) randImei() string {
-	return generateRandomMsisdn(15)
+// SplitImsiSupi splits an IMSI-type SUPI into its PLMN and MSIN. A SUPI of a configured PLMN ID
+// must belong to it; without one the MNC length comes from the MCC/MNC table.
+func SplitImsiSupi(supii string, plmnId models.PlmnId) (plmn.Plmn, string, error) {
+	imsi, ok := strings.CutPrefix(supii, IMSI_PREFIX+"-")
+	if !ok {
+		return plmn.Plmn{}, "", fmt.Errorf("not an IMSI-type SUPI: %s", supii)
+	}
+	if plmnId.Mcc == "" && plmnId.Mnc == "" {
+		return plmn.ParseImsi(imsi)
+	}
+	p, err := PlmnOf(plmnId)
+	if err != nil {
+		return plmn.Plmn{}, "", err
+	}
+	msin, err := p.SplitImsi(imsi)
+	if err != nil {
+		return plmn.Plmn{}, "", fmt.Errorf("invalid SUPI %s: %v", supii, err)
+	}
+	return p, msin, nil
 }
 
-// randImeiSv generates a random IMEISV
-func (o *Operator) randImeiSv() string {
-	return generateRandomMsisdn(16)
+// RecomputeSuci computes a fresh SUCI for a UE Profile from its SUPI, PLMN ID and home network key
+func RecomputeSuci(ue *models.UeProfile) (string, error) {
+	routingIndicator := ue.RoutingIndicator
+	if routingIndicator == "" {
+		routingIndicator = DEFAULT_ROUTING_INDICATOR
+	}
+	return ConcealSupi(ue.Supi, ue.PlmnId, routingIndicator, ue.ProtectionScheme, ue.HomeNetworkPublicKeyId, ue.HomeNetworkPublicKey)
 }
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"backend-webUE/models"
+	"testing"
+)
+
+func seededConfig(seed int64) *OperatorConfig {
+	return &OperatorConfig{
+		PlmnId: models.PlmnId{Mcc: "208", Mnc: "93"},
+		Profiles: []models.Profile{
+			{Scheme: A_SCHEME, PublicKey: "5a8d38864820197c3394b92613b20b91633cbd897119273bf8e4a6f4eec0a650"},
+			{Scheme: B_SCHEME, PublicKey: "0272da71976234ce833a6907425867b82e074d44ef907dfb4b3e21c1c2256ebcd1"},
+		},
+		Seed: &seed,
+	}
+}
+
+func TestGenerateUesWithSeed(t *testing.T) {
+	first, err := NewOperator(seededConfig(2024)).GenerateUes(5)
+	if err != nil {
+		t.Fatalf("GenerateUes failed: %v", err)
+	}
+	second, err := NewOperator(seededConfig(2024)).GenerateUes(5)
+	if err != nil {
+		t.Fatalf("GenerateUes failed: %v", err)
+	}
+
+	supis := make(map[string]bool)
+	for i := range first {
+		a, b := first[i], second[i]
+		if a.Supi != b.Supi || a.Suci != b.Suci || a.Key != b.Key || a.Op != b.Op || a.OpType != b.OpType ||
+			a.Imei != b.Imei || a.Imeisv != b.Imeisv || a.ProtectionScheme != b.ProtectionScheme {
+			t.Fatalf("UE %d differs between runs with the same seed:\n%+v\n%+v", i, a, b)
+		}
+		supis[a.Supi] = true
+	}
+	if len(supis) != len(first) {
+		t.Fatalf("seeded fleet repeats SUPIs: %v", supis)
+	}
+
+	other, err := NewOperator(seededConfig(2025)).GenerateUes(5)
+	if err != nil {
+		t.Fatalf("GenerateUes failed: %v", err)
+	}
+	if other[0].Supi == first[0].Supi && other[0].Key == first[0].Key {
+		t.Fatal("different seeds generated the same fleet")
+	}
+}
+
+func TestGenerateUesRejectsEmptyBatch(t *testing.T) {
+	if _, err := NewOperator(seededConfig(1)).GenerateUes(0); err == nil {
+		t.Fatal("GenerateUes accepted a batch of 0 UE Profiles")
+	}
+}
+
+func TestGenProfileSetsKeyId(t *testing.T) {
+	profiles := seededConfig(9).Profiles
+	ue := &models.UeProfile{}
+	if err := GenProfile(ue, B_SCHEME, 7, profiles); err != nil {
+		t.Fatalf("GenProfile failed: %v", err)
+	}
+	if ue.ProtectionScheme != B_SCHEME || ue.HomeNetworkPublicKeyId != 7 || ue.HomeNetworkPublicKey != profiles[1].PublicKey {
+		t.Fatalf("GenProfile = scheme %d, key ID %d, key %s", ue.ProtectionScheme, ue.HomeNetworkPublicKeyId, ue.HomeNetworkPublicKey)
+	}
+	if err := GenProfile(ue, NULL_SCHEME, 0, nil); err != nil || ue.HomeNetworkPublicKeyId != 0 || ue.HomeNetworkPublicKey != "" {
+		t.Fatalf("GenProfile of the null scheme = key ID %d, key %q, %v", ue.HomeNetworkPublicKeyId, ue.HomeNetworkPublicKey, err)
+	}
+
+	for _, tc := range []struct{ scheme, keyId int }{{A_SCHEME, 0}, {A_SCHEME, 256}, {NULL_SCHEME, 1}} {
+		if err := GenProfile(&models.UeProfile{}, tc.scheme, tc.keyId, profiles); err == nil {
+			t.Errorf("GenProfile accepted key ID %d for scheme %d", tc.keyId, tc.scheme)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"backend-webUE/models"
+	"backend-webUE/supi-key"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestThreeDigitMncGeneration(t *testing.T) {
+	plmnId := models.PlmnId{Mcc: "310", Mnc: "410"}
+	o := NewOperator(&OperatorConfig{
+		PlmnId:   plmnId,
+		Profiles: []models.Profile{{Scheme: A_SCHEME, PublicKey: "5a8d38864820197c3394b92613b20b91633cbd897119273bf8e4a6f4eec0a650"}},
+	})
+	ue, err := o.GenerateUe()
+	if err != nil {
+		t.Fatalf("GenerateUe failed: %v", err)
+	}
+	if !strings.HasPrefix(ue.Supi, "imsi-310410") || len(ue.Supi) != len("imsi-")+15 {
+		t.Fatalf("SUPI = %s, want a 15-digit IMSI of 310-410", ue.Supi)
+	}
+	suci, err := supi.ParseSuci(ue.Suci)
+	if err != nil {
+		t.Fatalf("ParseSuci failed: %v", err)
+	}
+	if suci.Mcc != "310" || suci.Mnc != "410" {
+		t.Fatalf("SUCI PLMN = %s-%s, want 310-410", suci.Mcc, suci.Mnc)
+	}
+
+	// The SUPI splits the same way with and without the operator's PLMN ID
+	for _, id := range []models.PlmnId{plmnId, {}} {
+		p, msin, err := SplitImsiSupi(ue.Supi, id)
+		if err != nil {
+			t.Fatalf("SplitImsiSupi failed: %v", err)
+		}
+		if p.String() != "310-410" || msin != ue.Supi[len("imsi-310410"):] {
+			t.Fatalf("SplitImsiSupi = %s, %s", p, msin)
+		}
+	}
+
+	// Exports keep the MNC digits
+	file := filepath.Join(t.TempDir(), "ue.yaml")
+	if err := ExportYAML(file, ue); err != nil {
+		t.Fatalf("ExportYAML failed: %v", err)
+	}
+	var imported models.UeProfile
+	if err := ImportYAML(file, &imported); err != nil {
+		t.Fatalf("ImportYAML failed: %v", err)
+	}
+	if imported.PlmnId != plmnId {
+		t.Fatalf("exported PLMN ID = %+v, want %+v", imported.PlmnId, plmnId)
+	}
+}
+
+func TestConcealSupiWithoutPlmnId(t *testing.T) {
+	suci, err := ConcealSupi("imsi-310410123456789", models.PlmnId{}, DEFAULT_ROUTING_INDICATOR, NULL_SCHEME, 0, "")
+	if err != nil {
+		t.Fatalf("ConcealSupi failed: %v", err)
+	}
+	if suci != "suci-0-310-410-0000-0-0-123456789" {
+		t.Fatalf("SUCI = %s", suci)
+	}
+
+	if _, err := ConcealSupi("imsi-310410123456789", models.PlmnId{Mcc: "310", Mnc: "41"}, DEFAULT_ROUTING_INDICATOR, NULL_SCHEME, 0, ""); err != nil {
+		t.Fatalf("ConcealSupi with the two-digit MNC 41 failed: %v", err)
+	}
+	if _, err := ConcealSupi("imsi-208930000000001", models.PlmnId{Mcc: "310", Mnc: "410"}, DEFAULT_ROUTING_INDICATOR, NULL_SCHEME, 0, ""); err == nil {
+		t.Fatal("ConcealSupi accepted a SUPI of another PLMN")
+	}
+}
@@ -0,0 +1,130 @@
+// utils/session.go
+package utils
+
+import (
+	"backend-webUE/models"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// PDU session types (TS 24.501 clause 9.11.4.11)
+const (
+	SESSION_TYPE_IPV4         = "IPv4"
+	SESSION_TYPE_IPV6         = "IPv6"
+	SESSION_TYPE_IPV4V6       = "IPv4v6"
+	SESSION_TYPE_ETHERNET     = "Ethernet"
+	SESSION_TYPE_UNSTRUCTURED = "Unstructured"
+)
+
+// SdLen is the length of a slice differentiator, 24 bits as hex digits (TS 23.003 clause 28.4.2)
+const SdLen = 6
+
+// ValidateSessionType checks a PDU session type
+func ValidateSessionType(sessionType string) error {
+	switch sessionType {
+	case SESSION_TYPE_IPV4, SESSION_TYPE_IPV6, SESSION_TYPE_IPV4V6, SESSION_TYPE_ETHERNET, SESSION_TYPE_UNSTRUCTURED:
+		return nil
+	default:
+		return fmt.Errorf("unsupported PDU session type %q", sessionType)
+	}
+}
+
+// NormalizeSd returns a slice differentiator in its stored form, 6 lowercase hex digits without
+// a 0x prefix: "0x010203" and "010203" are the same SD. An empty SD means the slice has none.
+func NormalizeSd(sd string) (string, error) {
+	if sd == "" {
+		return "", nil
+	}
+	digits := strings.ToLower(sd)
+	digits = strings.TrimPrefix(digits, "0x")
+	if len(digits) != SdLen {
+		return "", fmt.Errorf("invalid SD %q: must have %d hex digits", sd, SdLen)
+	}
+	if _, err := hex.DecodeString(digits); err != nil {
+		return "", fmt.Errorf("invalid SD %q: must have %d hex digits", sd, SdLen)
+	}
+	return digits, nil
+}
+
+// normalizeNssai returns a copy of an NSSAI with its SDs normalized
+func normalizeNssai(nssai []models.Snssai) ([]models.Snssai, error) {
+	if nssai == nil {
+		return nil, nil
+	}
+	normalized := make([]models.Snssai, len(nssai))
+	for i, snssai := range nssai {
+		sd, err := NormalizeSd(snssai.Sd)
+		if err != nil {
+			return nil, err
+		}
+		snssai.Sd = sd
+		normalized[i] = snssai
+	}
+	return normalized, nil
+}
+
+// NormalizeUeSds rewrites the SDs of a UE Profile's NSSAIs and session slices in their stored form.
+// The slices are replaced rather than modified, as generated UEs share them with the operator.
+func NormalizeUeSds(ue *models.UeProfile) error {
+	var err error
+	if ue.UeConfiguredNssai, err = normalizeNssai(ue.UeConfiguredNssai); err != nil {
+		return fmt.Errorf("configured NSSAI: %v", err)
+	}
+	if ue.UeDefaultNssai, err = normalizeNssai(ue.UeDefaultNssai); err != nil {
+		return fmt.Errorf("default NSSAI: %v", err)
+	}
+	if ue.Sessions == nil {
+		return nil
+	}
+	sessions := make([]models.Sessions, len(ue.Sessions))
+	for i, session := range ue.Sessions {
+		if session.Slice.Sd, err = NormalizeSd(session.Slice.Sd); err != nil {
+			return fmt.Errorf("session %d: %v", i+1, err)
+		}
+		sessions[i] = session
+	}
+	ue.Sessions = sessions
+	return nil
+}
+
+// NormalizeUeSessions normalizes the SDs of a UE Profile and checks each of its PDU sessions:
+// a supported session type, a DNN and an S-NSSAI of the UE's configured NSSAI
+func NormalizeUeSessions(ue *models.UeProfile) error {
+	if err := NormalizeUeSds(ue); err != nil {
+		return err
+	}
+	for i, session := range ue.Sessions {
+		if err := ValidateSessionType(session.Type); err != nil {
+			return fmt.Errorf("session %d: %v", i+1, err)
+		}
+		// The DNN is stored in the session's APN field
+		if session.Apn == "" {
+			return fmt.Errorf("session %d: DNN is required", i+1)
+		}
+		if !ContainsSnssai(ue.UeConfiguredNssai, session.Slice) {
+			return fmt.Errorf("session %d: S-NSSAI SST %d SD %q is not in the configured NSSAI", i+1, session.Slice.Sst, session.Slice.Sd)
+		}
+	}
+	return nil
+}
+
+// ContainsSnssai reports whether an NSSAI contains the S-NSSAI, whatever the form of their SDs
+func ContainsSnssai(nssai []models.Snssai, snssai models.Snssai) bool {
+	for _, s := range nssai {
+		if s.Sst == snssai.Sst && sameSd(s.Sd, snssai.Sd) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSd reports whether two SDs are equal once normalized
+func sameSd(a, b string) bool {
+	na, errA := NormalizeSd(a)
+	nb, errB := NormalizeSd(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return na == nb
+}
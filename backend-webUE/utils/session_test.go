@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"backend-webUE/models"
+	"testing"
+)
+
+func TestNormalizeSd(t *testing.T) {
+	for sd, want := range map[string]string{
+		"010203":   "010203",
+		"0x010203": "010203",
+		"0XFEDCBA": "fedcba",
+		"":         "",
+	} {
+		if got, err := NormalizeSd(sd); err != nil || got != want {
+			t.Errorf("NormalizeSd(%q) = %q, %v, want %q", sd, got, err, want)
+		}
+	}
+	for _, sd := range []string{"10203", "0x01020304", "01020g", "0x"} {
+		if _, err := NormalizeSd(sd); err == nil {
+			t.Errorf("NormalizeSd accepted %q", sd)
+		}
+	}
+}
+
+func TestNormalizeUeSessions(t *testing.T) {
+	configured := []models.Snssai{{Sst: 1, Sd: "010203"}, {Sst: 2}}
+	sessions := []models.Sessions{
+		{Type: SESSION_TYPE_IPV4, Apn: "internet", Slice: models.Snssai{Sst: 1, Sd: "0x010203"}},
+		{Type: SESSION_TYPE_ETHERNET, Apn: "lan", Slice: models.Snssai{Sst: 2}},
+	}
+	ue := &models.UeProfile{UeConfiguredNssai: configured, Sessions: sessions}
+	if err := NormalizeUeSessions(ue); err != nil {
+		t.Fatalf("NormalizeUeSessions failed: %v", err)
+	}
+	if ue.Sessions[0].Slice.Sd != "010203" {
+		t.Fatalf("session SD = %q, want 010203", ue.Sessions[0].Slice.Sd)
+	}
+	if sessions[0].Slice.Sd != "0x010203" {
+		t.Fatal("NormalizeUeSessions modified the sessions it was given")
+	}
+
+	for name, session := range map[string]models.Sessions{
+		"unknown type":       {Type: "PPP", Apn: "internet", Slice: models.Snssai{Sst: 2}},
+		"no DNN":             {Type: SESSION_TYPE_IPV6, Slice: models.Snssai{Sst: 2}},
+		"unconfigured slice": {Type: SESSION_TYPE_IPV6, Apn: "internet", Slice: models.Snssai{Sst: 1, Sd: "040506"}},
+		"invalid SD":         {Type: SESSION_TYPE_IPV6, Apn: "internet", Slice: models.Snssai{Sst: 1, Sd: "0x0102"}},
+	} {
+		ue := &models.UeProfile{UeConfiguredNssai: configured, Sessions: []models.Sessions{session}}
+		if err := NormalizeUeSessions(ue); err == nil {
+			t.Errorf("NormalizeUeSessions accepted a session with %s", name)
+		}
+	}
+}
+
+func TestGenerateUeNormalizesSds(t *testing.T) {
+	config := seededConfig(24)
+	config.UeConfiguredNssai = []models.Snssai{{Sst: 1, Sd: "0x010203"}}
+	config.Sessions = []models.Sessions{
+		{Type: SESSION_TYPE_IPV4V6, Apn: "internet", Slice: models.Snssai{Sst: 1, Sd: "010203"}},
+		{Type: SESSION_TYPE_UNSTRUCTURED, Apn: "iot", Slice: models.Snssai{Sst: 1, Sd: "0x010203"}},
+	}
+	ue, err := NewOperator(config).GenerateUe()
+	if err != nil {
+		t.Fatalf("GenerateUe failed: %v", err)
+	}
+	if ue.UeConfiguredNssai[0].Sd != "010203" || ue.Sessions[1].Slice.Sd != "010203" || len(ue.Sessions) != 2 {
+		t.Fatalf("generated slices not normalized: %+v %+v", ue.UeConfiguredNssai, ue.Sessions)
+	}
+	if config.UeConfiguredNssai[0].Sd != "0x010203" {
+		t.Fatal("GenerateUe modified the operator configuration")
+	}
+
+	config.Sessions[0].Slice.Sst = 2
+	if _, err := NewOperator(config).GenerateUe(); err == nil {
+		t.Fatal("GenerateUe accepted a session slice outside the configured NSSAI")
+	}
+}
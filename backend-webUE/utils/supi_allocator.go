@@ -0,0 +1,171 @@
+// utils/supi_allocator.go
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SUPI allocation modes
+const (
+	SUPI_ALLOC_RANDOM     = "random"
+	SUPI_ALLOC_SEQUENTIAL = "sequential"
+	SUPI_ALLOC_RANGE      = "range"
+)
+
+// naiUsernameDigits is the number of digits in the username of a generated NAI, nai-ue<digits>@<realm>
+const naiUsernameDigits = 10
+
+// maxRandomSupiAttempts bounds the random draws that may hit SUPIs already in use
+const maxRandomSupiAttempts = 100
+
+// SupiRegistry reports whether a SUPI is already provisioned
+type SupiRegistry interface {
+	SupiExists(supi string) (bool, error)
+}
+
+// SupiCollisionError reports a SUPI that is already provisioned
+type SupiCollisionError struct {
+	Supi string
+}
+
+func (e *SupiCollisionError) Error() string {
+	return fmt.Sprintf("SUPI %s already exists", e.Supi)
+}
+
+// supiAllocator is the SUPI allocation state of an Operator
+type supiAllocator struct {
+	mu sync.Mutex
+	// next is the next MSIN of sequential and range allocation, last the final one
+	next, last uint64
+	started    bool
+	// allocated holds the SUPIs handed out, so a batch never repeats one
+	allocated map[string]bool
+	// skipped holds the SUPIs passed over because the registry already has them
+	skipped []string
+}
+
+// SkippedSupis returns the SUPIs the operator passed over because they are already provisioned
+func (o *Operator) SkippedSupis() []string {
+	o.supis.mu.Lock()
+	defer o.supis.mu.Unlock()
+	return append([]string(nil), o.supis.skipped...)
+}
+
+// allocateSupi returns the next free SUPI of the operator's allocation mode
+func (o *Operator) allocateSupi() (string, error) {
+	o.supis.mu.Lock()
+	defer o.supis.mu.Unlock()
+
+	switch o.config.SupiAllocation {
+	case "", SUPI_ALLOC_RANDOM:
+		for attempt := 0; attempt < maxRandomSupiAttempts; attempt++ {
+			supi, err := o.randSupi()
+			if err != nil {
+				return "", err
+			}
+			ok, err := o.claimSupi(supi)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return supi, nil
+			}
+		}
+		return "", fmt.Errorf("no free SUPI found in %d random draws", maxRandomSupiAttempts)
+	case SUPI_ALLOC_SEQUENTIAL, SUPI_ALLOC_RANGE:
+		for {
+			supi, err := o.nextSequentialSupi()
+			if err != nil {
+				return "", err
+			}
+			ok, err := o.claimSupi(supi)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return supi, nil
+			}
+		}
+	default:
+		return "", fmt.Errorf("unsupported SUPI allocation mode %q", o.config.SupiAllocation)
+	}
+}
+
+// nextSequentialSupi returns the SUPI of the next MSIN of sequential or range allocation
+func (o *Operator) nextSequentialSupi() (string, error) {
+	n, err := o.supiDigits()
+	if err != nil {
+		return "", err
+	}
+	a := &o.supis
+	if !a.started {
+		if a.next, err = parseMsin(o.config.MsinStart, n); err != nil {
+			return "", fmt.Errorf("invalid start MSIN: %v", err)
+		}
+		a.last, _ = strconv.ParseUint(strings.Repeat("9", n), 10, 64)
+		if o.config.SupiAllocation == SUPI_ALLOC_RANGE {
+			if a.last, err = parseMsin(o.config.MsinEnd, n); err != nil {
+				return "", fmt.Errorf("invalid end MSIN: %v", err)
+			}
+			if a.last < a.next {
+				return "", fmt.Errorf("end MSIN %s is before start MSIN %s", o.config.MsinEnd, o.config.MsinStart)
+			}
+		}
+		a.started = true
+	}
+	// next passes last once the final MSIN was handed out
+	if a.next > a.last {
+		return "", fmt.Errorf("SUPI range %s-%s is exhausted", o.config.MsinStart, formatMsin(a.last, n))
+	}
+
+	msin := formatMsin(a.next, n)
+	a.next++
+	return o.formatSupi(msin)
+}
+
+// formatMsin formats an MSIN with n digits
+func formatMsin(msin uint64, n int) string {
+	return fmt.Sprintf("%0*d", n, msin)
+}
+
+// claimSupi reserves a SUPI unless the batch or the registry already has it
+func (o *Operator) claimSupi(supi string) (bool, error) {
+	a := &o.supis
+	if a.allocated[supi] {
+		return false, nil
+	}
+	if o.config.SupiRegistry != nil {
+		exists, err := o.config.SupiRegistry.SupiExists(supi)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			if o.config.ReportSupiCollisions {
+				return false, &SupiCollisionError{Supi: supi}
+			}
+			a.skipped = append(a.skipped, supi)
+			return false, nil
+		}
+	}
+	if a.allocated == nil {
+		a.allocated = make(map[string]bool)
+	}
+	a.allocated[supi] = true
+	return true, nil
+}
+
+// parseMsin parses an MSIN of exactly n digits
+func parseMsin(msin string, n int) (uint64, error) {
+	if len(msin) != n {
+		return 0, fmt.Errorf("%q must have %d digits", msin, n)
+	}
+	for _, c := range msin {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("%q must only have digits", msin)
+		}
+	}
+	return strconv.ParseUint(msin, 10, 64)
+}
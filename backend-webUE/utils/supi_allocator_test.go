@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"backend-webUE/models"
+	"errors"
+	"testing"
+)
+
+// fakeRegistry is a SupiRegistry with a fixed set of provisioned SUPIs
+type fakeRegistry map[string]bool
+
+func (r fakeRegistry) SupiExists(supi string) (bool, error) {
+	return r[supi], nil
+}
+
+func generateSupis(t *testing.T, config *OperatorConfig, count int) ([]string, *Operator, error) {
+	t.Helper()
+	config.PlmnId = models.PlmnId{Mcc: "208", Mnc: "93"}
+	o := NewOperator(config)
+	profiles, err := o.GenerateUes(count)
+	var supis []string
+	for _, ue := range profiles {
+		supis = append(supis, ue.Supi)
+	}
+	return supis, o, err
+}
+
+func TestSequentialSupiAllocation(t *testing.T) {
+	registry := fakeRegistry{"imsi-208930000000002": true}
+	supis, o, err := generateSupis(t, &OperatorConfig{
+		SupiAllocation: SUPI_ALLOC_SEQUENTIAL,
+		MsinStart:      "0000000001",
+		SupiRegistry:   registry,
+	}, 3)
+	if err != nil {
+		t.Fatalf("GenerateUes failed: %v", err)
+	}
+
+	want := []string{"imsi-208930000000001", "imsi-208930000000003", "imsi-208930000000004"}
+	for i := range want {
+		if supis[i] != want[i] {
+			t.Fatalf("SUPIs = %v, want %v", supis, want)
+		}
+	}
+	if skipped := o.SkippedSupis(); len(skipped) != 1 || skipped[0] != "imsi-208930000000002" {
+		t.Fatalf("SkippedSupis = %v", skipped)
+	}
+}
+
+func TestRangeSupiAllocation(t *testing.T) {
+	config := &OperatorConfig{
+		SupiAllocation: SUPI_ALLOC_RANGE,
+		MsinStart:      "0000000098",
+		MsinEnd:        "0000000099",
+	}
+	if supis, _, err := generateSupis(t, config, 2); err != nil || supis[1] != "imsi-208930000000099" {
+		t.Fatalf("GenerateUes = %v, %v", supis, err)
+	}
+	if _, _, err := generateSupis(t, config, 3); err == nil {
+		t.Fatal("GenerateUes allocated past the end of the range")
+	}
+
+	config.MsinEnd = "0000000097"
+	if _, _, err := generateSupis(t, config, 1); err == nil {
+		t.Fatal("GenerateUes accepted an end MSIN before the start MSIN")
+	}
+	config.MsinStart = "98"
+	if _, _, err := generateSupis(t, config, 1); err == nil {
+		t.Fatal("GenerateUes accepted a start MSIN of the wrong length")
+	}
+}
+
+func TestReportSupiCollisions(t *testing.T) {
+	_, _, err := generateSupis(t, &OperatorConfig{
+		SupiAllocation:       SUPI_ALLOC_SEQUENTIAL,
+		MsinStart:            "0000000001",
+		SupiRegistry:         fakeRegistry{"imsi-208930000000001": true},
+		ReportSupiCollisions: true,
+	}, 1)
+	var collision *SupiCollisionError
+	if !errors.As(err, &collision) || collision.Supi != "imsi-208930000000001" {
+		t.Fatalf("GenerateUes error = %v, want a collision on imsi-208930000000001", err)
+	}
+}
+
+func TestRandomSupiAllocationSkipsExisting(t *testing.T) {
+	seed := int64(5)
+	first, _, err := generateSupis(t, &OperatorConfig{Seed: &seed}, 1)
+	if err != nil {
+		t.Fatalf("GenerateUes failed: %v", err)
+	}
+
+	// The same seed draws the same SUPI first, which is now taken
+	supis, o, err := generateSupis(t, &OperatorConfig{Seed: &seed, SupiRegistry: fakeRegistry{first[0]: true}}, 1)
+	if err != nil {
+		t.Fatalf("GenerateUes failed: %v", err)
+	}
+	if supis[0] == first[0] || len(o.SkippedSupis()) != 1 {
+		t.Fatalf("GenerateUes reused %s, skipped %v", first[0], o.SkippedSupis())
+	}
+}
@@ -0,0 +1,252 @@
+// utils/validate.go
+package utils
+
+import (
+	"backend-webUE/milenage"
+	"backend-webUE/models"
+	"backend-webUE/supi-key"
+	"backend-webUE/tuak"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	MaxSst              = 255 // SST is 8 bits (TS 23.003 clause 28.4.2)
+	RoutingIndicatorLen = 4   // digits at most (TS 23.003 clause 2.2B)
+)
+
+// tuakKeyLens are the lengths in octets of a TUAK K, 128 or 256 bits (TS 35.231)
+var tuakKeyLens = []int{16, 32}
+
+// ValidationErrors lists the field errors of a UE Profile
+type ValidationErrors []models.FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Field + ": " + fieldError.Message
+	}
+	return "invalid UE Profile: " + strings.Join(messages, "; ")
+}
+
+// add records an error of a field
+func (e *ValidationErrors) add(field, format string, args ...interface{}) {
+	*e = append(*e, models.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateUeProfile checks every field of a UE Profile that the network relies on and returns all
+// field errors, or none when the profile is valid. Empty AMF and routing indicator fields are
+// allowed, as they default to DEFAULT_AMF and DEFAULT_ROUTING_INDICATOR, and so is an empty SUCI.
+func ValidateUeProfile(ue *models.UeProfile) ValidationErrors {
+	var errs ValidationErrors
+
+	// PLMN ID and SUPI
+	plmnValid := true
+	if len(ue.PlmnId.Mcc) != 3 || !isDigits(ue.PlmnId.Mcc) {
+		errs.add("plmnid.mcc", "MCC %q must have 3 digits", ue.PlmnId.Mcc)
+		plmnValid = false
+	}
+	if (len(ue.PlmnId.Mnc) != 2 && len(ue.PlmnId.Mnc) != 3) || !isDigits(ue.PlmnId.Mnc) {
+		errs.add("plmnid.mnc", "MNC %q must have 2 or 3 digits", ue.PlmnId.Mnc)
+		plmnValid = false
+	}
+	switch {
+	case strings.HasPrefix(ue.Supi, IMSI_PREFIX+"-"):
+		if _, _, err := SplitImsiSupi(ue.Supi, ue.PlmnId); err != nil && plmnValid {
+			errs.add("supi", "%v", err)
+		}
+	case strings.HasPrefix(ue.Supi, NAI_PREFIX+"-"):
+		if username, realm, ok := strings.Cut(strings.TrimPrefix(ue.Supi, NAI_PREFIX+"-"), "@"); !ok || username == "" || realm == "" {
+			errs.add("supi", "NAI %q must be nai-<username>@<realm>", ue.Supi)
+		}
+	default:
+		errs.add("supi", "SUPI %q must start with %s- or %s-", ue.Supi, IMSI_PREFIX, NAI_PREFIX)
+	}
+
+	// Slices and sessions
+	validateNssai(&errs, "ueConfiguredNssai", ue.UeConfiguredNssai)
+	validateNssai(&errs, "ueDefaultNssai", ue.UeDefaultNssai)
+	for i, session := range ue.Sessions {
+		field := fmt.Sprintf("sessions[%d]", i)
+		if err := ValidateSessionType(session.Type); err != nil {
+			errs.add(field+".type", "%v", err)
+		}
+		if session.Apn == "" {
+			errs.add(field+".apn", "DNN is required")
+		}
+		if validateSnssai(&errs, field+".slice", session.Slice) && !ContainsSnssai(ue.UeConfiguredNssai, session.Slice) {
+			errs.add(field+".slice", "S-NSSAI SST %d SD %q is not in the configured NSSAI", session.Slice.Sst, session.Slice.Sd)
+		}
+	}
+
+	// Subscription credentials
+	keyLens, opLen := []int{milenage.KeyLen}, milenage.KeyLen
+	switch ue.OpType {
+	case OP, OPC:
+	case TOP, TOPC:
+		keyLens, opLen = tuakKeyLens, tuak.TopLen
+	default:
+		errs.add("opType", "OP type %q must be %s, %s, %s or %s", ue.OpType, OP, OPC, TOP, TOPC)
+	}
+	if !isHexOfLen(ue.Key, keyLens...) {
+		errs.add("key", "K must be %s hex encoded", bitLengths(keyLens))
+	}
+	if !isHexOfLen(ue.Op, opLen) {
+		errs.add("op", "%s must be %s hex encoded", opName(ue.OpType), bitLengths([]int{opLen}))
+	}
+	if ue.Amf != "" && !isHexOfLen(ue.Amf, milenage.AmfLen) {
+		errs.add("amf", "AMF %q must be 16-bit hex encoded", ue.Amf)
+	}
+
+	// SUCI protection
+	if err := ValidateProfile(models.Profile{Scheme: ue.ProtectionScheme, PublicKey: ue.HomeNetworkPublicKey}); err != nil {
+		errs.add("protectionScheme", "%v", err)
+	}
+	if err := ValidateHomeNetworkKeyId(ue.ProtectionScheme, ue.HomeNetworkPublicKeyId); err != nil {
+		errs.add("homeNetworkPublicKeyId", "%v", err)
+	}
+	validateSuci(&errs, ue)
+	if ue.RoutingIndicator != "" && (len(ue.RoutingIndicator) > RoutingIndicatorLen || !isDigits(ue.RoutingIndicator)) {
+		errs.add("routingIndicator", "routing indicator %q must have 1 to %d digits", ue.RoutingIndicator, RoutingIndicatorLen)
+	}
+
+	// Equipment identities
+	var imeiErr, imeisvErr error
+	if ue.Imei != "" {
+		if imeiErr = ValidateImei(ue.Imei); imeiErr != nil {
+			errs.add("imei", "%v", imeiErr)
+		}
+	}
+	if ue.Imeisv != "" {
+		if imeisvErr = ValidateImeisv(ue.Imeisv); imeisvErr != nil {
+			errs.add("imeisv", "%v", imeisvErr)
+		}
+	}
+	if imeiErr == nil && imeisvErr == nil {
+		if err := ValidateUeImei(ue.Imei, ue.Imeisv); err != nil {
+			errs.add("imeisv", "%v", err)
+		}
+	}
+
+	// gNBs
+	for i, address := range ue.GnbSearchList {
+		if net.ParseIP(address) == nil {
+			errs.add(fmt.Sprintf("gnbSearchList[%d]", i), "%q is not an IP address", address)
+		}
+	}
+	return errs
+}
+
+// validateSuci records the errors of a UE Profile's SUCI: it must parse and agree with the UE's
+// SUPI, PLMN ID, protection scheme and home network public key ID. Only a null scheme SUCI shows
+// its MSIN or username; a concealed one cannot be checked against the SUPI without the private key.
+func validateSuci(errs *ValidationErrors, ue *models.UeProfile) {
+	if ue.Suci == "" {
+		return
+	}
+	suci, err := supi.ParseSuci(ue.Suci)
+	if err != nil {
+		errs.add("suci", "%v", err)
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(ue.Supi, IMSI_PREFIX+"-"):
+		if suci.SupiType != supi.SupiTypeImsi {
+			errs.add("suci", "SUCI of SUPI type %d does not match the IMSI-type SUPI", suci.SupiType)
+			break
+		}
+		if suci.Mcc != ue.PlmnId.Mcc || suci.Mnc != ue.PlmnId.Mnc {
+			errs.add("suci", "SUCI home network %s-%s does not match the PLMN ID %s-%s", suci.Mcc, suci.Mnc, ue.PlmnId.Mcc, ue.PlmnId.Mnc)
+			break
+		}
+		if _, msin, err := SplitImsiSupi(ue.Supi, ue.PlmnId); err == nil && suci.ProtectionSchemeId == NULL_SCHEME && suci.Msin != msin {
+			errs.add("suci", "SUCI MSIN %s does not match the SUPI %s", suci.Msin, ue.Supi)
+		}
+	case strings.HasPrefix(ue.Supi, NAI_PREFIX+"-"):
+		username, realm, _ := strings.Cut(strings.TrimPrefix(ue.Supi, NAI_PREFIX+"-"), "@")
+		if suci.SupiType != supi.SupiTypeNai {
+			errs.add("suci", "SUCI of SUPI type %d does not match the NAI-type SUPI", suci.SupiType)
+			break
+		}
+		if suci.HomeNetworkIdentifier != realm {
+			errs.add("suci", "SUCI realm %q does not match the SUPI realm %q", suci.HomeNetworkIdentifier, realm)
+			break
+		}
+		if suci.ProtectionSchemeId == NULL_SCHEME && suci.Username != username {
+			errs.add("suci", "SUCI username %q does not match the SUPI %s", suci.Username, ue.Supi)
+		}
+	}
+
+	if suci.ProtectionSchemeId != ue.ProtectionScheme {
+		errs.add("suci", "SUCI protection scheme %d does not match the protection scheme %d", suci.ProtectionSchemeId, ue.ProtectionScheme)
+	}
+	if suci.HomeNetworkPublicKeyId != ue.HomeNetworkPublicKeyId {
+		errs.add("suci", "SUCI home network public key ID %d does not match the key ID %d", suci.HomeNetworkPublicKeyId, ue.HomeNetworkPublicKeyId)
+	}
+}
+
+// validateNssai records the errors of the S-NSSAIs of an NSSAI
+func validateNssai(errs *ValidationErrors, field string, nssai []models.Snssai) {
+	for i, snssai := range nssai {
+		validateSnssai(errs, fmt.Sprintf("%s[%d]", field, i), snssai)
+	}
+}
+
+// validateSnssai records the errors of an S-NSSAI and reports whether it is valid
+func validateSnssai(errs *ValidationErrors, field string, snssai models.Snssai) bool {
+	valid := true
+	if snssai.Sst < 0 || snssai.Sst > MaxSst {
+		errs.add(field+".sst", "SST %d must be between 0 and %d", snssai.Sst, MaxSst)
+		valid = false
+	}
+	if _, err := NormalizeSd(snssai.Sd); err != nil {
+		errs.add(field+".sd", "%v", err)
+		valid = false
+	}
+	return valid
+}
+
+// isHexOfLen reports whether s is the hex encoding of one of the given numbers of octets
+func isHexOfLen(s string, octets ...int) bool {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	for _, n := range octets {
+		if len(decoded) == n {
+			return true
+		}
+	}
+	return false
+}
+
+// bitLengths describes octet lengths in bits, e.g. "128-bit or 256-bit"
+func bitLengths(octets []int) string {
+	lengths := make([]string, len(octets))
+	for i, n := range octets {
+		lengths[i] = fmt.Sprintf("%d-bit", 8*n)
+	}
+	return strings.Join(lengths, " or ")
+}
+
+// opName returns the name of the operator field stored for an OP type
+func opName(opType string) string {
+	switch opType {
+	case OPC:
+		return "OPc"
+	case TOP:
+		return "TOP"
+	case TOPC:
+		return "TOPc"
+	default:
+		return "OP"
+	}
+}
+
+// isDigits reports whether s is a non-empty string of decimal digits
+func isDigits(s string) bool {
+	return s != "" && checkDigits(s, len(s)) == nil
+}
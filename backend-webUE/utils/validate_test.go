@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"backend-webUE/models"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedUesAreValid(t *testing.T) {
+	config := seededConfig(25)
+	config.UeConfiguredNssai = []models.Snssai{{Sst: 1, Sd: "010203"}}
+	config.Sessions = []models.Sessions{{Type: SESSION_TYPE_IPV4, Apn: "internet", Slice: models.Snssai{Sst: 1, Sd: "0x010203"}}}
+	config.GnbSearchList = []string{"10.0.0.2", "2001:db8::1"}
+	config.Amf = DEFAULT_AMF
+	for _, algorithm := range []string{MILENAGE, TUAK} {
+		config.AuthAlgorithm = algorithm
+		profiles, err := NewOperator(config).GenerateUes(4)
+		if err != nil {
+			t.Fatalf("GenerateUes failed: %v", err)
+		}
+		for _, ue := range profiles {
+			if errs := ValidateUeProfile(&ue); len(errs) > 0 {
+				t.Fatalf("generated %s UE Profile is invalid: %v", algorithm, errs)
+			}
+		}
+	}
+}
+
+func TestValidateUeProfileReportsFields(t *testing.T) {
+	ue := &models.UeProfile{
+		Supi:              "imsi-20893000000001",
+		PlmnId:            models.PlmnId{Mcc: "208", Mnc: "9"},
+		UeConfiguredNssai: []models.Snssai{{Sst: 256, Sd: "010203"}},
+		UeDefaultNssai:    []models.Snssai{{Sst: 1, Sd: "01020"}},
+		Sessions:          []models.Sessions{{Type: SESSION_TYPE_IPV4, Apn: "internet", Slice: models.Snssai{Sst: 2}}},
+		Key:               "465b5ce8b199b49faa5f0a2ee238a6",
+		Op:                "cdc202d5123e20f62b6d676ac72cb318",
+		OpType:            OPC,
+		Amf:               "80000",
+		Imei:              "35145120840121",
+		RoutingIndicator:  "12345",
+		ProtectionScheme:  A_SCHEME,
+		GnbSearchList:     []string{"10.0.0.2", "gnb.local"},
+	}
+	errs := ValidateUeProfile(ue)
+
+	var fields []string
+	for _, fieldError := range errs {
+		fields = append(fields, fieldError.Field)
+	}
+	sort.Strings(fields)
+	want := []string{
+		"amf", "gnbSearchList[1]", "homeNetworkPublicKeyId", "imei", "key", "plmnid.mnc", "protectionScheme", "routingIndicator",
+		"sessions[0].slice", "ueConfiguredNssai[0].sst", "ueDefaultNssai[0].sd",
+	}
+	if strings.Join(fields, ",") != strings.Join(want, ",") {
+		t.Fatalf("field errors = %v, want %v", fields, want)
+	}
+	if !strings.Contains(errs.Error(), "plmnid.mnc: MNC \"9\" must have 2 or 3 digits") {
+		t.Fatalf("Error() = %q", errs.Error())
+	}
+}
+
+func TestValidateUeProfileTuakLengths(t *testing.T) {
+	ue := &models.UeProfile{
+		Supi:   "nai-ue1@nai.5gc.mnc093.mcc208.3gppnetwork.org",
+		PlmnId: models.PlmnId{Mcc: "208", Mnc: "93"},
+		Key:    strings.Repeat("ab", 32),
+		Op:     strings.Repeat("55", 32),
+		OpType: TOP,
+	}
+	if errs := ValidateUeProfile(ue); len(errs) > 0 {
+		t.Fatalf("ValidateUeProfile rejected a TUAK profile with a 256-bit K: %v", errs)
+	}
+	ue.Op = strings.Repeat("55", 16)
+	if errs := ValidateUeProfile(ue); len(errs) != 1 || errs[0].Field != "op" {
+		t.Fatalf("field errors = %v, want a 128-bit TOP rejected", errs)
+	}
+}
+
+func TestValidateUeProfileSuci(t *testing.T) {
+	const profileAKey = "5a8d38864820197c3394b92613b20b91633cbd897119273bf8e4a6f4eec0a650"
+	plmnId := models.PlmnId{Mcc: "208", Mnc: "93"}
+	suci := func(supi string, scheme, keyId int, publicKey string) string {
+		s, err := ConcealSupi(supi, plmnId, DEFAULT_ROUTING_INDICATOR, scheme, keyId, publicKey)
+		if err != nil {
+			t.Fatalf("ConcealSupi failed: %v", err)
+		}
+		return s
+	}
+	profile := func(supi string) *models.UeProfile {
+		return &models.UeProfile{
+			Supi:   supi,
+			PlmnId: plmnId,
+			Key:    "465b5ce8b199b49faa5f0a2ee238a6bc",
+			Op:     "cdc202d5123e20f62b6d676ac72cb318",
+			OpType: OPC,
+			Suci:   suci(supi, NULL_SCHEME, 0, ""),
+		}
+	}
+	const imsi, nai = "imsi-208930000000001", "nai-ue1@nai.5gc.mnc093.mcc208.3gppnetwork.org"
+
+	for _, supi := range []string{imsi, nai} {
+		if errs := ValidateUeProfile(profile(supi)); len(errs) > 0 {
+			t.Fatalf("ValidateUeProfile rejected the null scheme SUCI of %s: %v", supi, errs)
+		}
+	}
+	concealed := profile(imsi)
+	concealed.ProtectionScheme, concealed.HomeNetworkPublicKeyId, concealed.HomeNetworkPublicKey = A_SCHEME, 7, profileAKey
+	concealed.Suci = suci(imsi, A_SCHEME, 7, profileAKey)
+	if errs := ValidateUeProfile(concealed); len(errs) > 0 {
+		t.Fatalf("ValidateUeProfile rejected a Profile A SUCI: %v", errs)
+	}
+
+	profileA := func(ue *models.UeProfile, keyId int) {
+		ue.ProtectionScheme, ue.HomeNetworkPublicKeyId, ue.HomeNetworkPublicKey = A_SCHEME, keyId, profileAKey
+	}
+	for name, tc := range map[string]struct {
+		supi   string
+		change func(*models.UeProfile)
+		field  string
+	}{
+		"unparsable SUCI":      {imsi, func(ue *models.UeProfile) { ue.Suci = "suci-0-208-93" }, "suci"},
+		"SUCI of another MSIN": {imsi, func(ue *models.UeProfile) { ue.Suci = suci("imsi-208930000000002", NULL_SCHEME, 0, "") }, "suci"},
+		"SUCI of another PLMN": {imsi, func(ue *models.UeProfile) { ue.Suci = "suci-0-208-94-0000-0-0-0000000001" }, "suci"},
+		"NAI SUCI of an IMSI":  {imsi, func(ue *models.UeProfile) { ue.Suci = suci(nai, NULL_SCHEME, 0, "") }, "suci"},
+		"SUCI of another user": {nai, func(ue *models.UeProfile) {
+			ue.Suci = suci("nai-ue2@nai.5gc.mnc093.mcc208.3gppnetwork.org", NULL_SCHEME, 0, "")
+		}, "suci"},
+		"SUCI of another scheme": {imsi, func(ue *models.UeProfile) { profileA(ue, 7) }, "suci"},
+		"SUCI of another key ID": {imsi, func(ue *models.UeProfile) { profileA(ue, 8); ue.Suci = concealed.Suci }, "suci"},
+		"Profile A key ID 0":     {imsi, func(ue *models.UeProfile) { profileA(ue, 0) }, "homeNetworkPublicKeyId"},
+		"null scheme key ID 3":   {imsi, func(ue *models.UeProfile) { ue.HomeNetworkPublicKeyId = 3 }, "homeNetworkPublicKeyId"},
+	} {
+		ue := profile(tc.supi)
+		tc.change(ue)
+		errs := ValidateUeProfile(ue)
+		found := false
+		for _, fieldError := range errs {
+			found = found || fieldError.Field == tc.field
+		}
+		if !found {
+			t.Errorf("%s: field errors = %v, want an error of %s", name, errs, tc.field)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"backend-webUE/models"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,7 +9,8 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// ExportYAML writes a struct to a YAML file
+// ExportYAML writes a struct to a YAML file. UE Profiles are written with their authentication
+// algorithm; like every encoding of a UE Profile, the file leaves out the home network private key.
 func ExportYAML(filename string, data interface{}) error {
 	// Ensure the directory exists
 	dir := filepath.Dir(filename)
@@ -24,11 +26,59 @@ func ExportYAML(filename string, data interface{}) error {
 	defer file.Close()
 
 	// Marshal the data into YAML format
+	var node yaml.Node
+	if err := node.Encode(data); err != nil {
+		return fmt.Errorf("failed to marshal YAML: %v", err)
+	}
+	switch ue := data.(type) {
+	case models.UeProfile:
+		insertYAMLKey(&node, "optype", "authalgorithm", AuthAlgorithm(&ue))
+	case *models.UeProfile:
+		insertYAMLKey(&node, "optype", "authalgorithm", AuthAlgorithm(ue))
+	}
+
 	encoder := yaml.NewEncoder(file)
 	defer encoder.Close()
-	if err := encoder.Encode(data); err != nil {
+	if err := encoder.Encode(&node); err != nil {
 		return fmt.Errorf("failed to write YAML: %v", err)
 	}
 
 	return nil
 }
+
+// ImportYAML reads a YAML file written by ExportYAML into a struct
+func ImportYAML(filename string, out interface{}) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	if err := yaml.NewDecoder(file).Decode(out); err != nil {
+		return fmt.Errorf("failed to read YAML: %v", err)
+	}
+
+	return nil
+}
+
+// insertYAMLKey adds a string key to the top-level mapping of a YAML node right after another key
+func insertYAMLKey(node *yaml.Node, after, key, value string) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	pair := []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	}
+	at := len(node.Content)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == after {
+			at = i + 2
+			break
+		}
+	}
+	node.Content = append(node.Content[:at], append(pair, node.Content[at:]...)...)
+}
@@ -4,13 +4,27 @@ import axios from '../../api';
 import { toast } from 'react-toastify';
 import { Modal, Button, Form, Row, Col} from 'react-bootstrap';
 import { getToken } from '../../utils/auth';
+import { SESSION_TYPES, SD_PATTERN } from '../../utils/sessions';
+
+const DEFAULT_SESSION = { type: 'IPv4', apn: 'internet', slice: { sst: 0, sd: '' } };
 
 function GenerateUEProfileForm({ selectedProfile, onClose, refreshProfiles }) {
   const [formData, setFormData] = useState({
     num_ues: 1,
+    seed: '',
+    supiAllocation: 'random',
+    msinStart: '',
+    msinEnd: '',
+    tacs: '',
+    svn: '',
+    schemeShares: '',
+    opTypeShares: '',
+    sliceShares: '',
+    sessionTypeShares: '',
     plmnid: { mcc: '', mnc: '' },
     ueConfiguredNssai: [{ sst: 0, sd: '' }],
     ueDefaultNssai: [{ sst: 0, sd: '' }],
+    sessions: [{ ...DEFAULT_SESSION, slice: { ...DEFAULT_SESSION.slice } }],
     integrity: { IA1: true, IA2: true, IA3: true },
     ciphering: { EA1: true, EA2: true, EA3: true },
     uacAic: { mps: false, mcs: false },
@@ -23,14 +37,38 @@ function GenerateUEProfileForm({ selectedProfile, onClose, refreshProfiles }) {
       class15: false,
     },
   });
+  const [templates, setTemplates] = useState([]);
+  const [templateId, setTemplateId] = useState('');
+  const [templateName, setTemplateName] = useState('');
+
+  // **Shared fleet definitions from the ue_templates collection**
+  useEffect(() => {
+    if (selectedProfile) {
+      return;
+    }
+    axios.get('/ue_templates')
+      .then((response) => setTemplates(response.data || []))
+      .catch((error) => console.error('Error fetching UE templates:', error));
+  }, [selectedProfile]);
 
   useEffect(() => {
     if (selectedProfile) {
       setFormData({
         num_ues: 1, 
+        seed: '',
+        supiAllocation: 'random',
+        msinStart: '',
+        msinEnd: '',
+        tacs: '',
+        svn: '',
+        schemeShares: '',
+        opTypeShares: '',
+        sliceShares: '',
+        sessionTypeShares: '',
         plmnid: selectedProfile.plmnid || { mcc: '', mnc: '' },
         ueConfiguredNssai: selectedProfile.ueConfiguredNssai || [{ sst: 0, sd: '' }],
         ueDefaultNssai: selectedProfile.ueDefaultNssai || [{ sst: 0, sd: '' }],
+        sessions: selectedProfile.sessions || [{ ...DEFAULT_SESSION, slice: { ...DEFAULT_SESSION.slice } }],
         integrity: selectedProfile.integrity || { IA1: false, IA2: false, IA3: false },
         ciphering: selectedProfile.ciphering || { EA1: false, EA2: false, EA3: false },
         uacAic: selectedProfile.uacAic || { mps: false, mcs: false },
@@ -46,6 +84,98 @@ function GenerateUEProfileForm({ selectedProfile, onClose, refreshProfiles }) {
     }
   }, [selectedProfile]);
 
+  // **Fill the form from the selected template; edited fields are sent as overrides**
+  const handleTemplateChange = (e) => {
+    const id = e.target.value;
+    setTemplateId(id);
+    const template = templates.find((t) => t.id === id);
+    if (!template) {
+      return;
+    }
+    setFormData((prevData) => ({
+      ...prevData,
+      plmnid: template.plmnid,
+      ueConfiguredNssai: template.ueConfiguredNssai?.length ? template.ueConfiguredNssai : [{ sst: 0, sd: '' }],
+      ueDefaultNssai: template.ueDefaultNssai?.length ? template.ueDefaultNssai : [{ sst: 0, sd: '' }],
+      sessions: template.sessions || [],
+      integrity: template.integrity,
+      ciphering: template.ciphering,
+      uacAic: template.uacAic,
+      uacAcc: template.uacAcc,
+      tacs: (template.tacs || []).join(', '),
+      svn: template.svn || '',
+    }));
+  };
+
+  // **Fleet settings of the form, as a template or as template overrides**
+  const fleetSettings = () => ({
+    plmnid: formData.plmnid,
+    ueConfiguredNssai: formData.ueConfiguredNssai,
+    ueDefaultNssai: formData.ueDefaultNssai,
+    sessions: formData.sessions,
+    integrity: formData.integrity,
+    ciphering: formData.ciphering,
+    uacAic: formData.uacAic,
+    uacAcc: formData.uacAcc,
+    tacs: formData.tacs.split(',').map((tac) => tac.trim()).filter((tac) => tac !== ''),
+    svn: formData.svn,
+  });
+
+  // **Parse "value:percent" shares, e.g. "1:70, 2:30", into the batch distribution**
+  const parseShares = (text, label, toShare) => {
+    const shares = text.split(',').map((share) => share.trim()).filter((share) => share !== '');
+    if (shares.length === 0) {
+      return [];
+    }
+    let total = 0;
+    const parsed = shares.map((share) => {
+      const separator = share.lastIndexOf(':');
+      const percent = Number(share.slice(separator + 1));
+      if (separator <= 0 || !Number.isInteger(percent) || percent < 0) {
+        throw new Error(`${label} share "${share}" must be written value:percent.`);
+      }
+      total += percent;
+      return toShare(share.slice(0, separator).trim(), percent);
+    });
+    if (total !== 100) {
+      throw new Error(`${label} shares add up to ${total}%, not 100%.`);
+    }
+    return parsed;
+  };
+
+  const batchDistribution = () => {
+    const distribution = {
+      schemes: parseShares(formData.schemeShares, 'Scheme', (scheme, percent) => ({ scheme: parseInt(scheme, 10), percent })),
+      opTypes: parseShares(formData.opTypeShares, 'OP type', (opType, percent) => ({ opType, percent })),
+      slices: parseShares(formData.sliceShares, 'Slice', (slice, percent) => {
+        const [sst, sd = ''] = slice.split('-');
+        return { slice: { sst: parseInt(sst, 10), sd }, percent };
+      }),
+      sessionTypes: parseShares(formData.sessionTypeShares, 'Session type', (type, percent) => ({ type, percent })),
+    };
+    const empty = Object.values(distribution).every((shares) => shares.length === 0);
+    return empty ? undefined : distribution;
+  };
+
+  const handleSaveTemplate = async () => {
+    if (!templateName.trim()) {
+      toast.error('Template name is compulsory.');
+      return;
+    }
+    try {
+      const response = await axios.post('/ue_templates', {
+        name: templateName.trim(),
+        ...fleetSettings(),
+      });
+      setTemplates((prevTemplates) => [...prevTemplates, response.data]);
+      setTemplateId(response.data.id);
+      toast.success(`Template ${templateName.trim()} saved.`);
+    } catch (error) {
+      console.error('Error saving UE template:', error);
+      toast.error(error.response?.data?.error || 'An error occurred while saving the template.');
+    }
+  };
+
   const handleChange = (e) => {
     const { name, value, type, checked } = e.target;
     let newValue = type === 'checkbox' ? checked : value;
@@ -88,6 +218,30 @@ function GenerateUEProfileForm({ selectedProfile, onClose, refreshProfiles }) {
     });
   };
 
+  const handleSessionChange = (index, field, value) => {
+    setFormData((prevData) => {
+      const sessions = [...prevData.sessions];
+      sessions[index] = field.startsWith('slice.')
+        ? { ...sessions[index], slice: { ...sessions[index].slice, [field.slice(6)]: value } }
+        : { ...sessions[index], [field]: value };
+      return { ...prevData, sessions };
+    });
+  };
+
+  const addSession = () => {
+    setFormData((prevData) => ({
+      ...prevData,
+      sessions: [...prevData.sessions, { ...DEFAULT_SESSION, slice: { ...DEFAULT_SESSION.slice } }],
+    }));
+  };
+
+  const removeSession = (index) => {
+    setFormData((prevData) => ({
+      ...prevData,
+      sessions: prevData.sessions.filter((_, i) => i !== index),
+    }));
+  };
+
   const addSlice = (arrayName) => {
     setFormData((prevData) => ({
       ...prevData,
@@ -118,19 +272,63 @@ function GenerateUEProfileForm({ selectedProfile, onClose, refreshProfiles }) {
         toast.error('MCC and MNC of the PLMN ID are compulsory.');
         return;
       }
+      // **The MNC keeps its length: 93 and 093 are different PLMNs**
+      if (!/^\d{3}$/.test(formData.plmnid.mcc) || !/^\d{2,3}$/.test(formData.plmnid.mnc)) {
+        toast.error('The MCC must have 3 digits and the MNC 2 or 3 digits.');
+        return;
+      }
+
+      // **Every session needs a DNN and one of the configured slices; SDs are 6 hex digits**
+      const sameSd = (a, b) => a.replace(/^0x/i, '').toLowerCase() === b.replace(/^0x/i, '').toLowerCase();
+      const slices = [...formData.ueConfiguredNssai, ...formData.ueDefaultNssai, ...formData.sessions.map((session) => session.slice)];
+      if (slices.some((slice) => !SD_PATTERN.test(slice.sd))) {
+        toast.error('SDs must have 6 hex digits, e.g. 010203.');
+        return;
+      }
+      const invalidSession = formData.sessions.findIndex((session) => !session.apn
+        || !formData.ueConfiguredNssai.some((slice) => slice.sst === session.slice.sst && sameSd(slice.sd, session.slice.sd)));
+      if (invalidSession >= 0) {
+        toast.error(`Session ${invalidSession + 1} needs a DNN and one of the configured slices.`);
+        return;
+      }
+
+      // **An optional seed makes the generated fleet reproducible**
+      const seed = String(formData.seed).trim();
+      if (seed !== '' && !(/^-?\d+$/.test(seed) && Number.isSafeInteger(Number(seed)))) {
+        toast.error('Seed must be an integer.');
+        return;
+      }
+
+      // **Sequential and range allocation start from an MSIN, a range also ends at one**
+      if (formData.supiAllocation !== 'random' && !formData.msinStart) {
+        toast.error('Start MSIN is compulsory for sequential and range allocation.');
+        return;
+      }
+      if (formData.supiAllocation === 'range' && !formData.msinEnd) {
+        toast.error('End MSIN is compulsory for range allocation.');
+        return;
+      }
 
-      // **Create UE Profiles via the generate endpoint**
-      const payload = {
+      // **Create UE Profiles via the generate endpoint, from a template when one is selected**
+      const batch = {
         num_ues: formData.num_ues,
-        plmnid: formData.plmnid,
-        ueConfiguredNssai: formData.ueConfiguredNssai,
-        ueDefaultNssai: formData.ueDefaultNssai,
-        integrity: formData.integrity,
-        ciphering: formData.ciphering,
-        uacAic: formData.uacAic,
-        uacAcc: formData.uacAcc,
-        integrityMaxRate: formData.integrityMaxRate,
+        supiAllocation: formData.supiAllocation,
+        msinStart: formData.msinStart,
+        msinEnd: formData.msinEnd,
       };
+      let distribution;
+      try {
+        distribution = batchDistribution();
+      } catch (error) {
+        toast.error(error.message);
+        return;
+      }
+      const payload = templateId
+        ? { ...batch, templateId, overrides: { ...fleetSettings(), distribution } }
+        : { ...batch, ...fleetSettings(), integrityMaxRate: formData.integrityMaxRate, distribution };
+      if (seed !== '') {
+        payload.seed = Number(seed);
+      }
 
       await axios.post('/ue_profiles/generate', payload, {
         headers: {
@@ -173,6 +371,165 @@ function GenerateUEProfileForm({ selectedProfile, onClose, refreshProfiles }) {
               </Col>
             </Form.Group>
 
+            {/* Template */}
+            {!selectedProfile && (
+              <Form.Group as={Row} className="mb-3" controlId="templateId">
+                <Form.Label column sm={4}>Template:</Form.Label>
+                <Col sm={4}>
+                  <Form.Select value={templateId} onChange={handleTemplateChange}>
+                    <option value="">None</option>
+                    {templates.map((template) => (
+                      <option key={template.id} value={template.id}>{template.name}</option>
+                    ))}
+                  </Form.Select>
+                </Col>
+                <Col sm={3}>
+                  <Form.Control
+                    type="text"
+                    value={templateName}
+                    onChange={(e) => setTemplateName(e.target.value)}
+                    placeholder="New template name"
+                  />
+                </Col>
+                <Col sm={1}>
+                  <Button variant="outline-secondary" onClick={handleSaveTemplate}>
+                    Save
+                  </Button>
+                </Col>
+              </Form.Group>
+            )}
+
+            {/* SUPI allocation */}
+            <Form.Group as={Row} className="mb-3" controlId="supiAllocation">
+              <Form.Label column sm={4}>SUPI Allocation:</Form.Label>
+              <Col sm={4}>
+                <Form.Select
+                  name="supiAllocation"
+                  value={formData.supiAllocation}
+                  onChange={handleChange}
+                  disabled={!!selectedProfile} // Disable if updating
+                >
+                  <option value="random">Random</option>
+                  <option value="sequential">Sequential</option>
+                  <option value="range">Range</option>
+                </Form.Select>
+              </Col>
+              <Col sm={2}>
+                <Form.Control
+                  type="text"
+                  name="msinStart"
+                  value={formData.msinStart}
+                  onChange={handleChange}
+                  placeholder="Start MSIN"
+                  disabled={!!selectedProfile || formData.supiAllocation === 'random'}
+                />
+              </Col>
+              <Col sm={2}>
+                <Form.Control
+                  type="text"
+                  name="msinEnd"
+                  value={formData.msinEnd}
+                  onChange={handleChange}
+                  placeholder="End MSIN"
+                  disabled={!!selectedProfile || formData.supiAllocation !== 'range'}
+                />
+              </Col>
+            </Form.Group>
+
+            {/* TAC pool and SVN of the generated IMEIs */}
+            <Form.Group as={Row} className="mb-3" controlId="tacs">
+              <Form.Label column sm={4}>IMEI TACs / SVN:</Form.Label>
+              <Col sm={6}>
+                <Form.Control
+                  type="text"
+                  name="tacs"
+                  value={formData.tacs}
+                  onChange={handleChange}
+                  placeholder="8-digit TACs, comma separated (random when empty)"
+                  disabled={!!selectedProfile} // Disable if updating
+                />
+              </Col>
+              <Col sm={2}>
+                <Form.Control
+                  type="text"
+                  name="svn"
+                  value={formData.svn}
+                  onChange={handleChange}
+                  placeholder="01"
+                  maxLength={2}
+                  disabled={!!selectedProfile} // Disable if updating
+                />
+              </Col>
+            </Form.Group>
+
+            {/* Batch distribution */}
+            <Form.Group as={Row} className="mb-3" controlId="distribution">
+              <Form.Label column sm={4}>Distribution (optional):</Form.Label>
+              <Col sm={4}>
+                <Form.Control
+                  type="text"
+                  name="schemeShares"
+                  value={formData.schemeShares}
+                  onChange={handleChange}
+                  placeholder="Schemes, e.g. 1:70, 2:30"
+                  disabled={!!selectedProfile} // Disable if updating
+                />
+              </Col>
+              <Col sm={4}>
+                <Form.Control
+                  type="text"
+                  name="opTypeShares"
+                  value={formData.opTypeShares}
+                  onChange={handleChange}
+                  placeholder="OP types, e.g. OP:50, OPC:50"
+                  disabled={!!selectedProfile} // Disable if updating
+                />
+              </Col>
+              <Col sm={{ span: 4, offset: 4 }} className="mt-2">
+                <Form.Control
+                  type="text"
+                  name="sliceShares"
+                  value={formData.sliceShares}
+                  onChange={handleChange}
+                  placeholder="Slices, e.g. 1-010203:40, 2-010203:60"
+                  disabled={!!selectedProfile} // Disable if updating
+                />
+              </Col>
+              <Col sm={4} className="mt-2">
+                <Form.Control
+                  type="text"
+                  name="sessionTypeShares"
+                  value={formData.sessionTypeShares}
+                  onChange={handleChange}
+                  placeholder="Session types, e.g. IPv4:90, IPv6:10"
+                  disabled={!!selectedProfile} // Disable if updating
+                />
+              </Col>
+              <Col sm={{ span: 8, offset: 4 }}>
+                <Form.Text muted>
+                  Percentages of each list add up to 100 and each share must be a whole number of UEs (e.g. 70:30 needs a multiple of 10 UEs).
+                </Form.Text>
+              </Col>
+            </Form.Group>
+
+            {/* Seed */}
+            <Form.Group as={Row} className="mb-3" controlId="seed">
+              <Form.Label column sm={4}>Seed (optional):</Form.Label>
+              <Col sm={8}>
+                <Form.Control
+                  type="text"
+                  name="seed"
+                  value={formData.seed}
+                  onChange={handleChange}
+                  placeholder="Random"
+                  disabled={!!selectedProfile} // Disable if updating
+                />
+                <Form.Text muted>
+                  The same seed and settings regenerate the same SUPIs, keys, IMEIs and profile assignments. Only accepted when the backend runs in test mode.
+                </Form.Text>
+              </Col>
+            </Form.Group>
+
             {/* PLMN ID */}
             <Form.Group as={Row} className="mb-3">
               <Form.Label column sm={4}>PLMN ID:</Form.Label>
@@ -274,6 +631,58 @@ function GenerateUEProfileForm({ selectedProfile, onClose, refreshProfiles }) {
               Add Default Slice
             </Button>
 
+            {/* PDU Sessions */}
+            <h5 className="mt-4">PDU Sessions</h5>
+            {formData.sessions.map((session, index) => (
+              <Form.Group as={Row} className="mb-3" key={index}>
+                <Form.Label column sm={2}>Session {index + 1}:</Form.Label>
+                <Col sm={2}>
+                  <Form.Select
+                    value={session.type}
+                    onChange={(e) => handleSessionChange(index, 'type', e.target.value)}
+                  >
+                    {SESSION_TYPES.map((type) => (
+                      <option key={type} value={type}>{type}</option>
+                    ))}
+                  </Form.Select>
+                </Col>
+                <Col sm={3}>
+                  <Form.Control
+                    type="text"
+                    value={session.apn}
+                    onChange={(e) => handleSessionChange(index, 'apn', e.target.value)}
+                    placeholder="DNN"
+                    required
+                  />
+                </Col>
+                <Col sm={1}>
+                  <Form.Control
+                    type="number"
+                    value={session.slice.sst}
+                    onChange={(e) => handleSessionChange(index, 'slice.sst', parseInt(e.target.value, 10))}
+                    placeholder="SST"
+                    required
+                  />
+                </Col>
+                <Col sm={2}>
+                  <Form.Control
+                    type="text"
+                    value={session.slice.sd}
+                    onChange={(e) => handleSessionChange(index, 'slice.sd', e.target.value)}
+                    placeholder="SD"
+                  />
+                </Col>
+                <Col sm={2} className="d-flex align-items-center">
+                  <Button variant="danger" size="sm" onClick={() => removeSession(index)}>
+                    Remove
+                  </Button>
+                </Col>
+              </Form.Group>
+            ))}
+            <Button variant="secondary" onClick={addSession} className="mb-3">
+              Add Session
+            </Button>
+
             {/* Integrity */}
             <h5 className="mt-4">Integrity</h5>
             <Form.Group as={Row} className="mb-3">
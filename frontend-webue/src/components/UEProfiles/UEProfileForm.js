@@ -4,6 +4,7 @@ import { getToken } from '../../utils/auth';
 import { Form, Button, Row, Col, Card} from 'react-bootstrap';
 import PropTypes from 'prop-types';
 import { toast } from 'react-toastify';
+import { SESSION_TYPES } from '../../utils/sessions';
 
 function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
   const [formData, setFormData] = useState({
@@ -13,7 +14,6 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
     ueconfiguredNssai: [{ sst: 0, sd: '' }],
     uedefaultNssai: [{ sst: 0, sd: '' }],
     routingIndicator: '',
-    homeNetworkPrivateKey: '',
     homeNetworkPublicKey: '',
     homeNetworkPublicKeyId: 0,
     protectionScheme: 0,
@@ -51,7 +51,6 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
         ueConfiguredNssai: [{ sst: 0, sd: '' }],
         ueDefaultNssai: [{ sst: 0, sd: '' }],
         routingIndicator: '',
-        homeNetworkPrivateKey: '',
         homeNetworkPublicKey: '',
         homeNetworkPublicKeyId: 0,
         protectionScheme: 0,
@@ -138,7 +137,14 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
   const handleArrayChange = (fieldName, index, subFieldName, value) => {
     setFormData((prevData) => {
       const updatedArray = [...prevData[fieldName]];
-      if (subFieldName) {
+      if (subFieldName && subFieldName.includes('.')) {
+        // **Nested fields such as slice.sst of a session**
+        const [parent, child] = subFieldName.split('.');
+        updatedArray[index] = {
+          ...updatedArray[index],
+          [parent]: { ...updatedArray[index][parent], [child]: value },
+        };
+      } else if (subFieldName) {
         if (typeof updatedArray[index][subFieldName] === 'object') {
           updatedArray[index][subFieldName] = {
             ...updatedArray[index][subFieldName],
@@ -175,6 +181,22 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
     });
   };
 
+  // **Dry run: the backend reports every invalid field without saving**
+  const handleValidate = async () => {
+    const profile = selectedProfile ? { ...formData, supi: selectedProfile.supi } : formData;
+    try {
+      const response = await axios.post('/ue_profiles/validate', profile);
+      if (response.data.valid) {
+        toast.success('UE Profile is valid.');
+        return;
+      }
+      response.data.errors.forEach((fieldError) => toast.error(`${fieldError.field}: ${fieldError.message}`));
+    } catch (error) {
+      console.error('Error validating profile:', error);
+      toast.error(error.response?.data?.error || 'An error occurred while validating the UE Profile.');
+    }
+  };
+
   const handleSubmit = async (e) => {
     e.preventDefault();
     const token = getToken();
@@ -332,20 +354,6 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
             </Col>
           </Form.Group>
 
-          {/* Home Network Private Key */}
-          <Form.Group as={Row} className="mb-3" controlId="homeNetworkPrivateKey">
-            <Form.Label column sm={3}>Home Network Private Key:</Form.Label>
-            <Col sm={9}>
-              <Form.Control
-                type="text"
-                name="homeNetworkPrivateKey"
-                value={formData.homeNetworkPrivateKey || ''}
-                onChange={handleChange}
-                placeholder="Enter Home Network Private Key"
-              />
-            </Col>
-          </Form.Group>
-
           {/* Home Network Public Key */}
           <Form.Group as={Row} className="mb-3" controlId="homeNetworkPublicKey">
             <Form.Label column sm={3}>Home Network Public Key:</Form.Label>
@@ -440,8 +448,10 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
                 onChange={handleChange}
               >
                 <option value="">Select</option>
-                <option value="OP">OP</option>
-                <option value="OPC">OPC</option>
+                <option value="OP">OP (Milenage)</option>
+                <option value="OPC">OPC (Milenage)</option>
+                <option value="TOP">TOP (TUAK)</option>
+                <option value="TOPC">TOPC (TUAK)</option>
               </Form.Select>
             </Col>
           </Form.Group>
@@ -469,7 +479,7 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
                 name="imei"
                 value={formData.imei || ''}
                 onChange={handleChange}
-                placeholder="Enter IMEI"
+                placeholder="15 digits: TAC, serial number, check digit"
               />
             </Col>
           </Form.Group>
@@ -483,7 +493,7 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
                 name="imeisv"
                 value={formData.imeisv || ''}
                 onChange={handleChange}
-                placeholder="Enter IMEISV"
+                placeholder="16 digits: TAC, serial number, SVN"
               />
             </Col>
           </Form.Group>
@@ -609,25 +619,28 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
                 <Form.Group as={Row} className="mb-3" controlId={`sessions.${index}.type`}>
                   <Form.Label column sm={2}>Type:</Form.Label>
                   <Col sm={10}>
-                    <Form.Control
-                      type="text"
+                    <Form.Select
                       name={`sessions.${index}.type`}
                       value={session.type}
                       onChange={(e) => handleArrayChange('sessions', index, 'type', e.target.value)}
-                      placeholder="Enter Type"
                       required
-                    />
+                    >
+                      <option value="">Select Type</option>
+                      {SESSION_TYPES.map((type) => (
+                        <option key={type} value={type}>{type}</option>
+                      ))}
+                    </Form.Select>
                   </Col>
                 </Form.Group>
                 <Form.Group as={Row} className="mb-3" controlId={`sessions.${index}.apn`}>
-                  <Form.Label column sm={2}>APN:</Form.Label>
+                  <Form.Label column sm={2}>DNN:</Form.Label>
                   <Col sm={10}>
                     <Form.Control
                       type="text"
                       name={`sessions.${index}.apn`}
                       value={session.apn}
                       onChange={(e) => handleArrayChange('sessions', index, 'apn', e.target.value)}
-                      placeholder="Enter APN"
+                      placeholder="Enter DNN, e.g. internet"
                       required
                     />
                   </Col>
@@ -655,8 +668,7 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
                       name={`sessions.${index}.slice.sd`}
                       value={session.slice.sd}
                       onChange={(e) => handleArrayChange('sessions', index, 'slice.sd', e.target.value)}
-                      placeholder="Enter SD"
-                      required
+                      placeholder="6 hex digits, e.g. 010203, one of the configured slices"
                     />
                   </Col>
                 </Form.Group>
@@ -713,6 +725,9 @@ function UEProfileForm({ selectedProfile, onClose, onSubmit }) {
 
           {/* Submit and Cancel Buttons */}
           <div className="d-flex justify-content-end mt-4">
+            <Button variant="outline-primary" onClick={handleValidate} className="me-2">
+              Validate
+            </Button>
             <Button variant="success" type="submit" className="me-2">
               {selectedProfile ? 'Update' : 'Create'}
             </Button>
@@ -119,9 +119,6 @@ function UEProfileItem({ profile, onDelete, refreshProfiles }) {
               </ListGroup.Item>
 
               {/* Home Network Keys */}
-              <ListGroup.Item>
-                <strong>Home Network Private Key:</strong> {profile.homeNetworkPrivateKey || 'N/A'}
-              </ListGroup.Item>
               <ListGroup.Item>
                 <strong>Home Network Public Key:</strong> {profile.homeNetworkPublicKey || 'N/A'}
               </ListGroup.Item>
@@ -149,6 +146,10 @@ function UEProfileItem({ profile, onDelete, refreshProfiles }) {
               <ListGroup.Item>
                 <strong>OP Type:</strong> {profile.opType || 'N/A'}
               </ListGroup.Item>
+              <ListGroup.Item>
+                <strong>Authentication Algorithm:</strong>{' '}
+                {profile.opType === 'TOP' || profile.opType === 'TOPC' ? 'TUAK' : 'Milenage'}
+              </ListGroup.Item>
 
               {/* AMF */}
               <ListGroup.Item>
@@ -0,0 +1,5 @@
+// PDU session types the backend accepts (TS 24.501)
+export const SESSION_TYPES = ['IPv4', 'IPv6', 'IPv4v6', 'Ethernet', 'Unstructured'];
+
+// A slice differentiator is 6 hex digits, stored without 0x; an empty SD means none
+export const SD_PATTERN = /^((0x)?[0-9a-fA-F]{6})?$/;